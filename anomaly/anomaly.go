@@ -0,0 +1,107 @@
+// Package anomaly finds unusual parts of an input using the rule density of its sequitur grammar,
+// in the manner of GrammarViz: parts of the input covered by few rule occurrences are the least repetitive.
+/*
+	https://grammarviz2.github.io/grammarviz2_site/
+*/
+package anomaly
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// Density gives, for each terminal symbol of the input of comp, the number of the occurrences which cover it.
+// Symbols are counted rather than bytes, so that each rune of text, Token or SAX word counts once
+// however many bytes it is encoded in. The occurrences are normally those given by comp.Occurrences().
+func Density(comp *sequitur.Compact, occurrences []sequitur.Occurrence) []int {
+	if comp == nil || comp.RootID == sequitur.EmptySymbolID {
+		return nil
+	}
+	// the index of the symbol at each byte offset of the input, as Parse splits it
+	input := comp.Bytes(comp.RootID)
+	at := make([]int, len(input)+1)
+	n := 0
+	for i := 0; i < len(input); n++ {
+		_, size := utf8.DecodeRune(input[i:])
+		for j := i; j < i+size; j++ {
+			at[j] = n
+		}
+		i += size
+	}
+	at[len(input)] = n
+
+	delta := make([]int, n+1)
+	for _, o := range occurrences {
+		if o.Start < 0 || o.End > len(input) || o.Start >= o.End {
+			continue
+		}
+		delta[at[o.Start]]++
+		delta[at[o.End]]--
+	}
+	curve := make([]int, n)
+	d := 0
+	for i := range curve {
+		d += delta[i]
+		curve[i] = d
+	}
+	return curve
+}
+
+// Interval is a run of input symbols with a low rule density.
+// For text, the symbols are bytes for ASCII and runes otherwise, and for Tokens, the tokens.
+type Interval struct {
+	Start   int     // index of the first symbol of the interval in the input
+	End     int     // index of the symbol just after the interval
+	Density float64 // the mean rule density over the interval
+}
+
+// Options for Find.
+type Options struct {
+	Threshold int // the greatest rule density which counts as anomalous, 0 meaning symbols covered by no rules at all
+	MinLength int // the shortest interval reported, in symbols
+	Max       int // the most intervals reported, or all of them if zero
+}
+
+// Find gives the longest runs of the curve where the rule density is no more than opts.Threshold, ranked with the
+// lowest mean density first, then the longest, then the earliest.
+func Find(curve []int, opts Options) []Interval {
+	var found []Interval
+	for i := 0; i < len(curve); {
+		if curve[i] > opts.Threshold {
+			i++
+			continue
+		}
+		start, sum := i, 0
+		for ; i < len(curve) && curve[i] <= opts.Threshold; i++ {
+			sum += curve[i]
+		}
+		if i-start >= opts.MinLength {
+			found = append(found, Interval{
+				Start:   start,
+				End:     i,
+				Density: float64(sum) / float64(i-start),
+			})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Density != b.Density {
+			return a.Density < b.Density
+		}
+		if la, lb := a.End-a.Start, b.End-b.Start; la != lb {
+			return la > lb
+		}
+		return a.Start < b.Start
+	})
+	if opts.Max > 0 && len(found) > opts.Max {
+		found = found[:opts.Max]
+	}
+	return found
+}
+
+// Detect gives the ranked low-density intervals of the input of comp, using all of its rule occurrences.
+func Detect(comp *sequitur.Compact, opts Options) []Interval {
+	return Find(Density(comp, comp.Occurrences()), opts)
+}
@@ -0,0 +1,64 @@
+package anomaly
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+func ExampleDetect() {
+	input := []byte("abcdefabcdefabcdefabcdefXYZabcdefabcdefabcdefQ")
+	for _, iv := range Detect(sequitur.Parse(input).Compact(), Options{}) {
+		fmt.Printf("%d-%d %.1f %q\n", iv.Start, iv.End, iv.Density, input[iv.Start:iv.End])
+	}
+
+	// Output:
+	// 24-27 0.0 "XYZ"
+	// 45-46 0.0 "Q"
+}
+
+func TestDensity(t *testing.T) {
+	comp := sequitur.Parse([]byte("abcabcxabc")).Compact()
+	want := make([]int, 10)
+	for _, o := range comp.Occurrences() {
+		for i := o.Start; i < o.End; i++ {
+			want[i]++
+		}
+	}
+	if got := Density(comp, comp.Occurrences()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Density = %v, want %v", got, want)
+	}
+	// each rune counts once, however long its encoding
+	input := []rune("αβγαβγ€αβγ")
+	comp = sequitur.Parse([]byte(string(input))).Compact()
+	curve := Density(comp, comp.Occurrences())
+	if len(curve) != len(input) {
+		t.Errorf("Density of %d runes has %d entries", len(input), len(curve))
+	}
+	if got, want := Find(curve, Options{}), []Interval{{Start: 6, End: 7}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Find = %v, want %v", got, want)
+	}
+	if got := Density(sequitur.Parse(nil).Compact(), nil); got != nil {
+		t.Errorf("Density of empty grammar = %v", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	curve := []int{3, 0, 0, 3, 1, 1, 1, 3, 0, 3, 0, 0}
+	got := Find(curve, Options{Threshold: 1})
+	want := []Interval{
+		{Start: 1, End: 3, Density: 0},
+		{Start: 10, End: 12, Density: 0},
+		{Start: 8, End: 9, Density: 0},
+		{Start: 4, End: 7, Density: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find = %v, want %v", got, want)
+	}
+	got = Find(curve, Options{Threshold: 1, MinLength: 2, Max: 2})
+	if !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("Find with MinLength and Max = %v, want %v", got, want[:2])
+	}
+}
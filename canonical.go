@@ -0,0 +1,85 @@
+package sequitur
+
+// Canonical gives a copy of the grammar with its rules renumbered in the order they are first met
+// in a depth-first walk of the root, which is numbered as the top-level rule of a parsed grammar.
+// Grammars of the same structure have identical canonical forms, whatever order their rules were created in.
+// Rules not reachable from the root are dropped.
+func (comp *Compact) Canonical() *Compact {
+	c := &Compact{
+		RootID: EmptySymbolID,
+		Map:    make(map[SymbolID]CompactEntry),
+	}
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return c
+	}
+	ids := make(map[SymbolID]SymbolID)
+	next := SymbolID(firstRuleID)
+	var walk func(id SymbolID) SymbolID
+	walk = func(id SymbolID) SymbolID {
+		if nid, ok := ids[id]; ok {
+			return nid
+		}
+		nid := next
+		next++
+		ids[id] = nid
+		entry := comp.Map[id]
+		renumbered := CompactEntry{
+			Used: entry.Used,
+			IDs:  make(SymbolIDslice, len(entry.IDs)),
+		}
+		for i, sid := range entry.IDs {
+			if sid.IsRule() {
+				sid = walk(sid)
+			}
+			renumbered.IDs[i] = sid
+		}
+		c.Map[nid] = renumbered
+		return nid
+	}
+	c.RootID = walk(comp.RootID)
+	return c
+}
+
+// Equal says if two grammars have the same structure, differing at most in the numbering of their rules.
+// Used counts, and rules not reachable from the root, are not compared. A nil grammar equals an empty one.
+func Equal(a, b *Compact) bool {
+	root := func(comp *Compact) SymbolID {
+		if comp == nil {
+			return EmptySymbolID
+		}
+		return comp.RootID
+	}
+	ra, rb := root(a), root(b)
+	if ra == EmptySymbolID || rb == EmptySymbolID {
+		return ra == rb
+	}
+
+	aToB := make(map[SymbolID]SymbolID)
+	bToA := make(map[SymbolID]SymbolID)
+	var same func(x, y SymbolID) bool
+	same = func(x, y SymbolID) bool {
+		if mapped, ok := aToB[x]; ok {
+			return mapped == y
+		}
+		if _, ok := bToA[y]; ok {
+			return false
+		}
+		aToB[x], bToA[y] = y, x
+		xs, ys := a.Map[x].IDs, b.Map[y].IDs
+		if len(xs) != len(ys) {
+			return false
+		}
+		for i := range xs {
+			switch {
+			case xs[i].IsRule() && ys[i].IsRule():
+				if !same(xs[i], ys[i]) {
+					return false
+				}
+			case xs[i] != ys[i]:
+				return false
+			}
+		}
+		return true
+	}
+	return same(ra, rb)
+}
@@ -0,0 +1,68 @@
+package sequitur
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleCompact_Canonical() {
+	comp := Parse([]byte("abcdbcabcd")).Compact()
+	fmt.Print(comp.Canonical())
+
+	// Output:
+	// 1114369 -> {0 [1114370 1114371 1114370]}
+	// 1114370 -> {2 [a 1114371 d]}
+	// 1114371 -> {2 [b c]}
+}
+
+// renumber gives a copy of comp with every rule ID shifted by offset.
+func renumber(comp *Compact, offset SymbolID) *Compact {
+	r := &Compact{RootID: comp.RootID + offset, Map: make(map[SymbolID]CompactEntry)}
+	for id, entry := range comp.Map {
+		ids := make(SymbolIDslice, len(entry.IDs))
+		for i, sid := range entry.IDs {
+			if sid.IsRule() {
+				sid += offset
+			}
+			ids[i] = sid
+		}
+		r.Map[id+offset] = CompactEntry{Used: entry.Used, IDs: ids}
+	}
+	return r
+}
+
+func TestEqual(t *testing.T) {
+	a := Parse([]byte("abcdbcabcd")).Compact()
+	b := renumber(a, 1000)
+	empty := Parse(nil).Compact()
+
+	// use the two rules of the root the other way round
+	swapped := renumber(a, 0)
+	root := swapped.Map[swapped.RootID]
+	r1, r2 := root.IDs[0], root.IDs[1]
+	swapped.Map[swapped.RootID] = CompactEntry{IDs: SymbolIDslice{r2, r1, r2}}
+
+	for _, tc := range []struct {
+		name string
+		a, b *Compact
+		want bool
+	}{
+		{"same", a, a, true},
+		{"renumbered", a, b, true},
+		{"different input", a, Parse([]byte("abcdbcabce")).Compact(), false},
+		{"different rules", a, swapped, false},
+		{"empty", empty, nil, true},
+		{"empty and not", a, empty, false},
+	} {
+		if got := Equal(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: Equal(a, b) = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := Equal(tc.b, tc.a); got != tc.want {
+			t.Errorf("%s: Equal(b, a) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if c, d := a.Canonical(), b.Canonical(); c.String() != d.String() || !Equal(c, a) {
+		t.Errorf("canonical forms differ:\n%s\n%s", c, d)
+	}
+}
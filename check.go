@@ -0,0 +1,49 @@
+package sequitur
+
+import "fmt"
+
+// Check that comp can be expanded: that the root is a rule, that every rule it uses is defined and none contains itself,
+// and that valid gives true for every terminal, or that no terminal is negative if valid is nil.
+// It is for grammars which did not come from a Grammar, such as those read from a file.
+func (comp *Compact) Check(valid func(sid SymbolID) bool) error {
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return nil
+	}
+	if !comp.RootID.IsRule() {
+		return fmt.Errorf("grammar has root %d, which is not a rule", comp.RootID)
+	}
+	if valid == nil {
+		valid = func(sid SymbolID) bool { return sid >= 0 }
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[SymbolID]int)
+	var visit func(id SymbolID) error
+	visit = func(id SymbolID) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("grammar has rule %v containing itself", id)
+		case done:
+			return nil
+		}
+		entry, ok := comp.Map[id]
+		if !ok {
+			return fmt.Errorf("grammar has no rule %v", id)
+		}
+		state[id] = visiting
+		for _, sid := range entry.IDs {
+			if sid.IsRule() {
+				if err := visit(sid); err != nil {
+					return err
+				}
+			} else if !valid(sid) {
+				return fmt.Errorf("grammar has invalid terminal %d", sid)
+			}
+		}
+		state[id] = done
+		return nil
+	}
+	return visit(comp.RootID)
+}
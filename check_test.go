@@ -0,0 +1,33 @@
+package sequitur
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	if err := Parse([]byte(testString)).Compact().Check(nil); err != nil {
+		t.Errorf("parsed grammar: %v", err)
+	}
+	if err := Parse(nil).Compact().Check(nil); err != nil {
+		t.Errorf("empty grammar: %v", err)
+	}
+
+	root := SymbolID(firstRuleID)
+	a := SymbolID(newRune('a'))
+	for _, tc := range []struct {
+		name  string
+		comp  *Compact
+		valid func(SymbolID) bool
+	}{
+		{"terminal root", &Compact{RootID: a}, nil},
+		{"undefined rule", &Compact{RootID: root, Map: map[SymbolID]CompactEntry{root: {IDs: SymbolIDslice{a, root + 1}}}}, nil},
+		{"rule containing itself", &Compact{RootID: root, Map: map[SymbolID]CompactEntry{
+			root:     {IDs: SymbolIDslice{root + 1, a}},
+			root + 1: {IDs: SymbolIDslice{a, root + 1}},
+		}}, nil},
+		{"negative terminal", &Compact{RootID: root, Map: map[SymbolID]CompactEntry{root: {IDs: SymbolIDslice{a, -2}}}}, nil},
+		{"invalid terminal", &Compact{RootID: root, Map: map[SymbolID]CompactEntry{root: {IDs: SymbolIDslice{a}}}}, func(sid SymbolID) bool { return sid != a }},
+	} {
+		if err := tc.comp.Check(tc.valid); err == nil {
+			t.Errorf("%s: no error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sequitur
+
+import "io"
+
+// Clone gives an independent copy of the grammar, which may be read or changed without affecting the original.
+// Hooks are not copied.
+func (g *Grammar) Clone() *Grammar {
+	if g == nil {
+		return nil
+	}
+	c := &Grammar{
+		ruleID: g.ruleID,
+		nrules: g.nrules,
+	}
+	if g.base == nil {
+		return c
+	}
+
+	ruleMap := make(map[*rules]*rules)
+	symbolMap := make(map[*symbols]*symbols)
+	var cloneRule func(r *rules) *rules
+	cloneRule = func(r *rules) *rules {
+		if nr, ok := ruleMap[r]; ok {
+			return nr
+		}
+		nr := &rules{id: r.id, count: r.count}
+		nr.guard = c.newGuard(nr)
+		ruleMap[r] = nr
+		for p := r.first(); !p.isGuard(); p = p.next {
+			s := &symbols{g: c, value: p.value}
+			if p.isNonTerminal() {
+				s.rule = cloneRule(p.rule)
+			}
+			nr.last().insertAfter(s)
+			symbolMap[p] = s
+		}
+		return nr
+	}
+	c.base = cloneRule(g.base)
+
+	c.table = make(digrams, len(g.table))
+	for d, s := range g.table {
+		if ns, ok := symbolMap[s]; ok {
+			c.table[d] = ns
+		}
+	}
+	return c
+}
+
+// Snapshot is a read-only copy of a Grammar, which may be used by many goroutines at once,
+// even while the grammar it was taken from continues to be built.
+type Snapshot struct {
+	g *Grammar
+}
+
+// Snapshot takes a read-only copy of the grammar.
+func (g *Grammar) Snapshot() *Snapshot {
+	return &Snapshot{g: g.Clone()}
+}
+
+// Print reconstructs the input to w.
+func (s *Snapshot) Print(w io.Writer) error {
+	return s.g.Print(w)
+}
+
+// PrettyPrint outputs the grammar to w.
+func (s *Snapshot) PrettyPrint(w io.Writer) error {
+	return s.g.PrettyPrint(w)
+}
+
+// Symbol provides the top-level symbol of the grammar.
+func (s *Snapshot) Symbol() *Symbol {
+	return s.g.Symbol()
+}
+
+// Compact returns the Compact representation of the grammar.
+func (s *Snapshot) Compact() *Compact {
+	return s.g.Compact()
+}
@@ -0,0 +1,85 @@
+package sequitur
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func prettyPrinted(t *testing.T, pp func(w *bytes.Buffer) error) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := pp(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestClone(t *testing.T) {
+	g := Parse([]byte("abcabcabd"))
+	c := g.Clone()
+
+	// continue both, differently, and check each matches parsing its whole input at once
+	g.appendBytes([]byte("abcabd"))
+	c.appendBytes([]byte("xabcx"))
+	for _, tc := range []struct {
+		g     *Grammar
+		input string
+	}{
+		{g, "abcabcabdabcabd"},
+		{c, "abcabcabdxabcx"},
+	} {
+		got := prettyPrinted(t, func(w *bytes.Buffer) error { return tc.g.PrettyPrint(w) })
+		want := prettyPrinted(t, func(w *bytes.Buffer) error { return Parse([]byte(tc.input)).PrettyPrint(w) })
+		if got != want {
+			t.Errorf("%q: got\n%s\nwant\n%s", tc.input, got, want)
+		}
+		if p := prettyPrinted(t, func(w *bytes.Buffer) error { return tc.g.Print(w) }); p != tc.input {
+			t.Errorf("got input %q, want %q", p, tc.input)
+		}
+		if tc.g.nrules != len(tc.g.Compact().Map) {
+			t.Errorf("%q: nrules %d, but %d rules", tc.input, tc.g.nrules, len(tc.g.Compact().Map))
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	input := strings.Repeat("the quick brown fox jumps over the lazy dog ", 20)
+	var snapshots []*Snapshot
+	hooks := &Hooks{
+		SymbolAppended: func(g *Grammar, sym SymbolID) {
+			if len(snapshots) < 50 {
+				snapshots = append(snapshots, g.Snapshot())
+			}
+		},
+	}
+	g, err := ParseContext(context.Background(), strings.NewReader(input), ParseOptions{Hooks: hooks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots = append(snapshots, g.Snapshot())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n, s := range snapshots {
+				want := input[:n+1]
+				if n == len(snapshots)-1 {
+					want = input
+				}
+				var b bytes.Buffer
+				if err := s.Print(&b); err != nil || b.String() != want {
+					t.Errorf("snapshot %d: got %q, want %q", n, b.String(), want)
+				}
+				if got := string(s.Compact().Bytes(s.Symbol().ID())); got != want {
+					t.Errorf("snapshot %d: compact gives %q, want %q", n, got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
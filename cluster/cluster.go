@@ -0,0 +1,298 @@
+// Package cluster groups documents by the similarity of their sequitur grammars.
+package cluster
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// Linkage selects how the similarity between two clusters is derived from the similarities of their members.
+type Linkage int
+
+const (
+	Single   Linkage = iota // the similarity of the most similar pair of members
+	Complete                // the similarity of the least similar pair of members
+	Average                 // the mean similarity over all pairs of members
+)
+
+// Node of a Dendrogram. A leaf represents a single document and has nil Left and Right.
+type Node struct {
+	Left, Right *Node
+	Doc         int     // the index of the document for a leaf, -1 otherwise
+	Similarity  float64 // the similarity at which Left and Right were merged, 1 for a leaf
+	Size        int     // the number of documents below this node
+}
+
+// IsLeaf says if the node represents a single document.
+func (n *Node) IsLeaf() bool { return n.Left == nil && n.Right == nil }
+
+// Members gives the indexes of the documents below this node, in ascending order.
+func (n *Node) Members() []int {
+	var members []int
+	var walk func(*Node)
+	walk = func(n *Node) {
+		if n.IsLeaf() {
+			members = append(members, n.Doc)
+			return
+		}
+		walk(n.Left)
+		walk(n.Right)
+	}
+	walk(n)
+	sort.Ints(members)
+	return members
+}
+
+// Dendrogram is the result of hierarchical clustering.
+type Dendrogram struct {
+	Root *Node // nil if there were no documents
+	docs []*sequitur.CompactIndexed
+}
+
+// Cluster is a group of similar documents.
+type Cluster struct {
+	Members []int        // indexes of the documents, in ascending order
+	Shared  []SharedRule // the rule strings found in every member, if there is more than one
+}
+
+// SharedRule is a rule string common to all the members of a Cluster.
+type SharedRule struct {
+	String   string
+	Coverage float64 // the mean proportion of each member's input represented by the rule
+}
+
+// Agglomerative clusters the docs hierarchically, repeatedly merging the two most similar clusters according to
+// Similarity and the given linkage, computing the similarity matrix with up to workers goroutines (GOMAXPROCS if workers < 1).
+func Agglomerative(docs []*sequitur.CompactIndexed, linkage Linkage, workers int) *Dendrogram {
+	n := len(docs)
+	d := &Dendrogram{docs: docs}
+	if n == 0 {
+		return d
+	}
+
+	m := sequitur.SimilarityMatrix(docs, workers)
+	sim := make([][]float64, n)
+	nodes := make([]*Node, n)
+	active := make([]bool, n)
+	for i := range sim {
+		sim[i] = make([]float64, n)
+		for j := range sim[i] {
+			sim[i][j] = m.At(i, j)
+		}
+		nodes[i] = &Node{Doc: i, Similarity: 1, Size: 1}
+		active[i] = true
+	}
+
+	// nearest-neighbour chain, valid as all the linkages are reducible
+	var chain []int
+	for remaining := n; remaining > 1; remaining-- {
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+		for {
+			a := chain[len(chain)-1]
+			prev := -1
+			if len(chain) > 1 {
+				prev = chain[len(chain)-2]
+			}
+			b := prev
+			for c := range active {
+				if !active[c] || c == a {
+					continue
+				}
+				if b == -1 || sim[a][c] > sim[a][b] {
+					b = c
+				}
+			}
+			if b == prev {
+				break
+			}
+			chain = append(chain, b)
+		}
+		a, b := chain[len(chain)-1], chain[len(chain)-2]
+		chain = chain[:len(chain)-2]
+		if b < a {
+			a, b = b, a
+		}
+
+		na, nb := float64(nodes[a].Size), float64(nodes[b].Size)
+		for c := range active {
+			if !active[c] || c == a || c == b {
+				continue
+			}
+			var s float64
+			switch linkage {
+			case Single:
+				s = math.Max(sim[a][c], sim[b][c])
+			case Complete:
+				s = math.Min(sim[a][c], sim[b][c])
+			default:
+				s = (na*sim[a][c] + nb*sim[b][c]) / (na + nb)
+			}
+			sim[a][c], sim[c][a] = s, s
+		}
+		nodes[a] = &Node{
+			Left:       nodes[a],
+			Right:      nodes[b],
+			Doc:        -1,
+			Similarity: sim[a][b],
+			Size:       nodes[a].Size + nodes[b].Size,
+		}
+		nodes[b] = nil
+		active[b] = false
+	}
+
+	for i := range active {
+		if active[i] {
+			d.Root = nodes[i]
+		}
+	}
+	return d
+}
+
+// Cut the dendrogram into the clusters whose members were all merged at a similarity of at least threshold.
+func (d *Dendrogram) Cut(threshold float64) []Cluster {
+	if d == nil || d.Root == nil {
+		return nil
+	}
+	var clusters []Cluster
+	var walk func(*Node)
+	walk = func(n *Node) {
+		if n.IsLeaf() || n.Similarity >= threshold {
+			clusters = append(clusters, newCluster(d.docs, n.Members()))
+			return
+		}
+		walk(n.Left)
+		walk(n.Right)
+	}
+	walk(d.Root)
+	sortClusters(clusters)
+	return clusters
+}
+
+// WriteText draws the dendrogram to w as an indented tree, labelling each leaf with the document index
+// followed by its entry in names, if there is one.
+func (d *Dendrogram) WriteText(w io.Writer, names []string) error {
+	if d == nil || d.Root == nil {
+		return nil
+	}
+	var walk func(n *Node, prefix, branch, indent string) error
+	walk = func(n *Node, prefix, branch, indent string) error {
+		if n.IsLeaf() {
+			label := fmt.Sprint(n.Doc)
+			if n.Doc < len(names) {
+				label += " " + names[n.Doc]
+			}
+			_, err := fmt.Fprintln(w, prefix+branch+label)
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%.5f\n", prefix, branch, n.Similarity); err != nil {
+			return err
+		}
+		if err := walk(n.Left, prefix+indent, "+-- ", "|   "); err != nil {
+			return err
+		}
+		return walk(n.Right, prefix+indent, "`-- ", "    ")
+	}
+	return walk(d.Root, "", "", "")
+}
+
+// Threshold clusters the docs by linking every pair with a Similarity of at least threshold, computing the
+// similarity matrix with up to workers goroutines (GOMAXPROCS if workers < 1).
+func Threshold(docs []*sequitur.CompactIndexed, threshold float64, workers int) []Cluster {
+	n := len(docs)
+	m := sequitur.SimilarityMatrix(docs, workers)
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			if m.At(i, j) >= threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+	clusters := make([]Cluster, 0, len(groups))
+	for _, members := range groups {
+		clusters = append(clusters, newCluster(docs, members))
+	}
+	sortClusters(clusters)
+	return clusters
+}
+
+func sortClusters(clusters []Cluster) {
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Members[0] < clusters[j].Members[0] })
+}
+
+func newCluster(docs []*sequitur.CompactIndexed, members []int) Cluster {
+	c := Cluster{Members: members}
+	if len(members) > 1 {
+		c.Shared = Explain(docs, members)
+	}
+	return c
+}
+
+// Explain gives the rule strings found in every one of the given docs, in descending order of mean coverage.
+func Explain(docs []*sequitur.CompactIndexed, members []int) []SharedRule {
+	if len(members) == 0 {
+		return nil
+	}
+	smallest := docs[members[0]]
+	for _, m := range members {
+		if docs[m] == nil {
+			return nil
+		}
+		if len(docs[m].StringToID) < len(smallest.StringToID) {
+			smallest = docs[m]
+		}
+	}
+
+	var shared []SharedRule
+outer:
+	for str := range smallest.StringToID {
+		coverage := 0.0
+		for _, m := range members {
+			sid, found := docs[m].StringToID[str]
+			if !found {
+				continue outer
+			}
+			coverage += docs[m].IDinfo[sid].Coverage
+		}
+		shared = append(shared, SharedRule{
+			String:   str,
+			Coverage: coverage / float64(len(members)),
+		})
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].Coverage == shared[j].Coverage {
+			return strings.Compare(shared[i].String, shared[j].String) < 0
+		}
+		return shared[i].Coverage > shared[j].Coverage
+	})
+	return shared
+}
@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+var testDocs = []string{
+	"pease porridge hot, pease porridge cold, pease porridge in the pot, nine days old.",
+	"some like it hot, some like it cold, some like it in the pot, nine days old.",
+	"the quick brown fox jumps over the lazy dog, the quick brown fox jumps again.",
+	"pease porridge hot, pease porridge cold, pease porridge in the pot, nine days old!",
+	"the quick brown fox jumps over the lazy cat, the quick brown fox jumps again.",
+}
+
+func index(texts []string) []*sequitur.CompactIndexed {
+	docs := make([]*sequitur.CompactIndexed, len(texts))
+	for i, text := range texts {
+		docs[i] = sequitur.Parse([]byte(text)).Compact().Index(nil)
+	}
+	return docs
+}
+
+func ExampleDendrogram_WriteText() {
+	d := Agglomerative(index(testDocs), Average, 0)
+	if err := d.WriteText(os.Stdout, []string{"pease", "some", "fox", "pease!", "fox/cat"}); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// 0.00000
+	// +-- 0.07452
+	// |   +-- 0.35433
+	// |   |   +-- 0 pease
+	// |   |   `-- 3 pease!
+	// |   `-- 1 some
+	// `-- 0.28704
+	//     +-- 2 fox
+	//     `-- 4 fox/cat
+}
+
+func TestAgglomerative(t *testing.T) {
+	docs := index(testDocs)
+	for _, linkage := range []Linkage{Single, Complete, Average} {
+		d := Agglomerative(docs, linkage, 2)
+		if d.Root.Size != len(docs) {
+			t.Errorf("linkage %d: root has %d members, want %d", linkage, d.Root.Size, len(docs))
+		}
+		got := d.Cut(0.2)
+		var members [][]int
+		for _, c := range got {
+			members = append(members, c.Members)
+		}
+		if want := [][]int{{0, 3}, {1}, {2, 4}}; !reflect.DeepEqual(members, want) {
+			t.Errorf("linkage %d: Cut(0.2) = %v, want %v", linkage, members, want)
+		}
+		if got[1].Shared != nil {
+			t.Errorf("linkage %d: singleton has shared rules %v", linkage, got[1].Shared)
+		}
+		if len(got[2].Shared) == 0 || got[2].Shared[0].String == "" {
+			t.Errorf("linkage %d: no shared rules for %v", linkage, got[2].Members)
+		}
+	}
+	if d := Agglomerative(nil, Single, 0); d.Root != nil || d.Cut(0) != nil {
+		t.Error("empty input produced clusters")
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	docs := index(testDocs)
+	var members [][]int
+	for _, c := range Threshold(docs, 0.2, 0) {
+		members = append(members, c.Members)
+	}
+	if want := [][]int{{0, 3}, {1}, {2, 4}}; !reflect.DeepEqual(members, want) {
+		t.Errorf("Threshold(0.2) = %v, want %v", members, want)
+	}
+	if got := Threshold(docs, 0, 0); len(got) != 1 {
+		t.Errorf("Threshold(0) gave %d clusters, want 1", len(got))
+	}
+}
+
+func TestExplain(t *testing.T) {
+	docs := index(testDocs)
+	for _, sr := range Explain(docs, []int{0, 3}) {
+		for _, m := range []int{0, 3} {
+			if _, found := docs[m].StringToID[sr.String]; !found {
+				t.Errorf("shared rule %q not in document %d", sr.String, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// Ways of splitting an input into terminal symbols.
+const (
+	byRune = "rune"
+	byWord = "word"
+	byLine = "line"
+)
+
+// document is the grammar of an input, as saved by the compact command.
+type document struct {
+	Tokenization string                                      `json:"tokenization"`
+	Tokens       []token                                     `json:"tokens,omitempty"` // in the order they were first seen, for word and line tokenization
+	Root         sequitur.SymbolID                           `json:"root"`
+	Rules        map[sequitur.SymbolID]sequitur.CompactEntry `json:"rules"`
+
+	tokens sequitur.Tokens
+}
+
+// token is a word or line of the input. It is saved as a JSON string if it is valid UTF-8,
+// and otherwise as an object holding its bytes in base64, as JSON strings cannot hold arbitrary bytes.
+type token string
+
+type tokenBytes struct {
+	Bytes []byte `json:"bytes"`
+}
+
+func (t token) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(string(t)) {
+		return json.Marshal(string(t))
+	}
+	return json.Marshal(tokenBytes{[]byte(t)})
+}
+
+func (t *token) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var b tokenBytes
+		err := json.Unmarshal(data, &b)
+		*t = token(b.Bytes)
+		return err
+	}
+	return json.Unmarshal(data, (*string)(t))
+}
+
+// inputFlags are the flags of the commands which read an input or a saved grammar.
+type inputFlags struct {
+	tokenization string
+	algorithm    string
+	optimize     string
+	strict       bool
+	saved        bool
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.tokenization, "t", byRune, "split the input into `symbols` by rune, word or line")
+	fs.StringVar(&f.algorithm, "a", "sequitur", "build the grammar with `algorithm`: sequitur, or repair for the usually smaller grammars of Re-Pair")
+	fs.StringVar(&f.optimize, "optimize", "", "optimize the grammar for `cost`: symbols, or bytes for the size of a simple encoding")
+	fs.BoolVar(&f.strict, "strict", false, "with -optimize, keep the rules whose removal would save nothing")
+	fs.BoolVar(&f.saved, "g", false, "read a grammar saved by compact rather than an input, which cannot be used with -a or -t")
+}
+
+// load the document named by the first argument left in fs.
+func (f *inputFlags) load(e *env, fs *flag.FlagSet) (*document, error) {
+	if fs.NArg() > 1 {
+		return nil, fmt.Errorf("too many arguments: %q", fs.Args()[1:])
+	}
+	if f.saved {
+		// a saved grammar has already been split into symbols and built
+		var conflict string
+		fs.Visit(func(fl *flag.Flag) {
+			if fl.Name == "a" || fl.Name == "t" {
+				conflict = fl.Name
+			}
+		})
+		if conflict != "" {
+			fmt.Fprintf(e.stderr, "flag -%s cannot be used with -g\n", conflict)
+			fs.Usage()
+			return nil, errUsage
+		}
+	}
+	input, err := readInput(e, fs.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+	costs := map[string]sequitur.CostModel{"symbols": sequitur.CostSymbols, "bytes": sequitur.CostBytes}
+	cost, ok := costs[f.optimize]
+	if !ok && f.optimize != "" {
+		return nil, fmt.Errorf("unknown cost %q: want symbols or bytes", f.optimize)
+	}
+	var d *document
+	if f.saved {
+		d, err = loadDocument(input)
+	} else {
+		d, err = parseDocument(input, f.tokenization, f.algorithm)
+	}
+	if err != nil || f.optimize == "" {
+		return d, err
+	}
+	opt, _ := d.compact().Optimize(sequitur.OptimizeOptions{Cost: cost, Strict: f.strict})
+	return d.setCompact(opt), nil
+}
+
+// readInput reads the named file, or standard input if name is empty or "-".
+func readInput(e *env, name string) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// algorithms build grammars, by the names given to -a.
+var algorithms = map[string]func(input []byte) *sequitur.Compact{
+	"sequitur": func(input []byte) *sequitur.Compact { return sequitur.Parse(input).Compact() },
+	"repair":   sequitur.RePair,
+}
+
+// parseDocument builds the grammar of input, split into symbols by tokenization, using the named algorithm.
+func parseDocument(input []byte, tokenization, algorithm string) (*document, error) {
+	build, ok := algorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm %q: want sequitur or repair", algorithm)
+	}
+	d := &document{Tokenization: tokenization}
+	var split func([]byte) [][]byte
+	switch tokenization {
+	case byRune:
+		return d.setCompact(build(input)), nil
+	case byWord:
+		split = splitWords
+	case byLine:
+		split = splitLines
+	default:
+		return nil, fmt.Errorf("unknown tokenization %q: want rune, word or line", tokenization)
+	}
+	var runes []byte
+	for _, tok := range split(input) {
+		var err error
+		if runes, err = d.appendToken(runes, string(tok)); err != nil {
+			return nil, err
+		}
+	}
+	return d.setCompact(build(runes)), nil
+}
+
+// loadDocument reads a grammar saved by compact, checking that it can be expanded.
+func loadDocument(data []byte) (*document, error) {
+	var saved document
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	d := &document{
+		Tokenization: saved.Tokenization,
+		Root:         saved.Root,
+		Rules:        saved.Rules,
+	}
+	switch d.Tokenization {
+	case byRune:
+	case byWord, byLine:
+		for _, tok := range saved.Tokens {
+			if _, err := d.appendToken(nil, string(tok)); err != nil {
+				return nil, err
+			}
+		}
+		if len(d.Tokens) != len(saved.Tokens) {
+			return nil, errors.New("saved grammar has repeated tokens")
+		}
+	default:
+		return nil, fmt.Errorf("saved grammar has unknown tokenization %q", d.Tokenization)
+	}
+	if d.Rules == nil {
+		d.Rules = make(map[sequitur.SymbolID]sequitur.CompactEntry)
+	}
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// check that the rules of the document are all defined, that none of them contains itself, and that every terminal is known.
+func (d *document) check() error {
+	return d.compact().Check(func(sid sequitur.SymbolID) bool {
+		_, ok := d.terminal(sid)
+		return ok
+	})
+}
+
+// appendToken appends the rune representing tok to b, recording tok if it is new.
+func (d *document) appendToken(b []byte, tok string) ([]byte, error) {
+	n := d.tokens.Len()
+	b, err := d.tokens.Append(b, tok)
+	if d.tokens.Len() > n {
+		d.Tokens = append(d.Tokens, token(tok))
+	}
+	return b, err
+}
+
+func (d *document) setCompact(comp *sequitur.Compact) *document {
+	d.Root, d.Rules = comp.RootID, comp.Map
+	return d
+}
+
+func (d *document) compact() *sequitur.Compact {
+	return &sequitur.Compact{RootID: d.Root, Map: d.Rules}
+}
+
+// terminal gives the text of a terminal symbol.
+func (d *document) terminal(sid sequitur.SymbolID) (string, bool) {
+	if d.Tokenization == byRune {
+		return string(sid.Bytes(d.compact())), sid >= 0
+	}
+	return d.tokens.Token(sid)
+}
+
+// expand writes the input of the document to w.
+func (d *document) expand(w io.Writer) error {
+	if d.Root == sequitur.EmptySymbolID {
+		return nil
+	}
+	return d.expandRule(w, d.Root)
+}
+
+// expandRule writes the expansion of a rule to w.
+func (d *document) expandRule(w io.Writer, id sequitur.SymbolID) error {
+	if d.Tokenization == byRune {
+		_, err := w.Write(d.compact().Bytes(id))
+		return err
+	}
+	for _, sid := range d.Rules[id].IDs {
+		if sid.IsRule() {
+			if err := d.expandRule(w, sid); err != nil {
+				return err
+			}
+			continue
+		}
+		tok, _ := d.tokens.Token(sid)
+		if _, err := io.WriteString(w, tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitWords splits input into runs of letters and digits, runs of white space, and single other characters.
+func splitWords(input []byte) [][]byte {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r):
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	var words [][]byte
+	start, prev := 0, -1
+	for i := 0; i < len(input); {
+		r, sz := utf8.DecodeRune(input[i:])
+		c := class(r)
+		if i > start && (c != prev || c == 0) {
+			words = append(words, input[start:i])
+			start = i
+		}
+		prev = c
+		i += sz
+	}
+	if start < len(input) {
+		words = append(words, input[start:])
+	}
+	return words
+}
+
+// splitLines splits input into lines, each ending with its newline except perhaps the last.
+func splitLines(input []byte) [][]byte {
+	var lines [][]byte
+	start := 0
+	for i, b := range input {
+		if b == '\n' {
+			lines = append(lines, input[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(input) {
+		lines = append(lines, input[start:])
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+func runDot(e *env, args []string) error {
+	fs := e.flags("dot", "[file]")
+	var in inputFlags
+	in.register(fs)
+	expansions := fs.Bool("expansions", false, "label each rule with its expansion as well as its symbols")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	d, err := in.load(e, fs)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(e.stdout)
+	fmt.Fprintln(w, "digraph grammar {")
+	fmt.Fprintln(w, "\tnode [shape=box, fontname=monospace];")
+	comp := d.compact()
+	order, number := comp.Appearance()
+	rule := d.printer(sequitur.Printer{}).RuleFormatter(comp)
+	for i, id := range order {
+		label := rule(id)
+		uses := make(map[sequitur.SymbolID]int)
+		var children []sequitur.SymbolID
+		for _, sid := range d.Rules[id].IDs {
+			if sid.IsRule() {
+				if uses[sid] == 0 {
+					children = append(children, sid)
+				}
+				uses[sid]++
+			}
+		}
+		if *expansions {
+			var b strings.Builder
+			d.expandRule(&b, id)
+			label += "\n" + strconv.Quote(b.String())
+		}
+		fmt.Fprintf(w, "\tr%d [label=%s];\n", i, dotQuote(label))
+		for _, sid := range children {
+			if n := uses[sid]; n > 1 {
+				fmt.Fprintf(w, "\tr%d -> r%d [label=\"×%d\"];\n", i, number[sid], n)
+			} else {
+				fmt.Fprintf(w, "\tr%d -> r%d;\n", i, number[sid])
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// dotQuote quotes s as a DOT string, in which only quotes and backslashes are escaped, and newlines separate lines of a label.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,22 @@
+package main
+
+import "fmt"
+
+func runExpand(e *env, args []string) error {
+	fs := e.flags("expand", "[grammar.json]")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("too many arguments: %q", fs.Args()[1:])
+	}
+	data, err := readInput(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	d, err := loadDocument(data)
+	if err != nil {
+		return err
+	}
+	return d.expand(e.stdout)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+const exploreHelp = `commands:
+  rules         list every rule
+  rule N        show rule N, how often it is used and where it occurs
+  expand N      show the expansion of rule N
+  parents N     list the rules which use rule N
+  find TEXT     list where TEXT occurs and the smallest rules containing it
+  top K         list the K rules covering the most of the input
+  help          show this list
+  quit          leave
+rules are numbered as by the grammar command, with 0 for the top-level rule
+`
+
+// explorer answers questions about a document.
+type explorer struct {
+	d      *document
+	rel    *sequitur.Relations
+	w      io.Writer
+	order  []sequitur.SymbolID // the rules in the order they are numbered
+	number map[sequitur.SymbolID]int
+	rule   func(id sequitur.SymbolID) string // formats a rule as PrettyPrint does
+	input  []byte
+	starts map[sequitur.SymbolID][]int // the byte offsets of the occurrences of each rule
+	length map[sequitur.SymbolID]int   // the length in bytes of the expansion of each rule
+}
+
+func runExplore(e *env, args []string) error {
+	fs := e.flags("explore", "file")
+	var in inputFlags
+	in.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || fs.Arg(0) == "-" {
+		return fmt.Errorf("want one file, as commands are read from standard input")
+	}
+	d, err := in.load(e, fs)
+	if err != nil {
+		return err
+	}
+	x := newExplorer(d, e.stdout)
+
+	// only prompt when someone is typing
+	prompt := ""
+	if f, ok := e.stdin.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			prompt = "> "
+			fmt.Fprintf(e.stdout, "%d rules; type help for the commands\n", len(x.order))
+		}
+	}
+	sc := bufio.NewScanner(e.stdin)
+	for fmt.Fprint(e.stdout, prompt); sc.Scan(); fmt.Fprint(e.stdout, prompt) {
+		cmd, arg := sc.Text(), ""
+		if i := strings.IndexByte(cmd, ' '); i >= 0 {
+			cmd, arg = cmd[:i], cmd[i+1:]
+		}
+		if cmd == "quit" || cmd == "exit" {
+			return nil
+		}
+		if err := x.run(cmd, arg); err != nil {
+			fmt.Fprintln(e.stdout, "error:", err)
+		}
+	}
+	return sc.Err()
+}
+
+func newExplorer(d *document, w io.Writer) *explorer {
+	x := &explorer{
+		d:      d,
+		rel:    d.compact().Relations(),
+		w:      w,
+		starts: make(map[sequitur.SymbolID][]int),
+		length: make(map[sequitur.SymbolID]int),
+	}
+	x.order, x.number = d.compact().Appearance()
+	x.rule = d.printer(sequitur.Printer{}).RuleFormatter(d.compact())
+	var b bytes.Buffer
+	_ = d.expand(&b) // writing to a bytes.Buffer cannot fail
+	x.input = b.Bytes()
+
+	if len(x.order) == 0 {
+		return x
+	}
+	// the occurrences are found in the input as the grammar has it, where each token is a single rune
+	comp := d.compact()
+	offset := func(i int) int { return i }
+	if d.Tokenization != byRune {
+		lengths := make(map[rune]int, len(d.Tokens))
+		for _, tok := range d.Tokens {
+			r, _ := d.tokens.Rune(string(tok)) // tokens already seen are never reallocated
+			lengths[r] = len(tok)
+		}
+		encoded := comp.Bytes(d.Root)
+		at := make([]int, len(encoded)+1)
+		for i := 0; i < len(encoded); {
+			r, size := utf8.DecodeRune(encoded[i:])
+			at[i+size] = at[i] + lengths[r]
+			i += size
+		}
+		offset = func(i int) int { return at[i] }
+	}
+	x.starts[d.Root], x.length[d.Root] = []int{0}, len(x.input)
+	for _, o := range comp.Occurrences() {
+		x.starts[o.ID] = append(x.starts[o.ID], offset(o.Start))
+		x.length[o.ID] = offset(o.End) - offset(o.Start)
+	}
+	return x
+}
+
+func (x *explorer) run(cmd, arg string) error {
+	switch cmd {
+	case "":
+		return nil
+	case "help", "?":
+		_, err := io.WriteString(x.w, exploreHelp)
+		return err
+	case "rules":
+		return x.d.prettyPrint(x.w, sequitur.Printer{})
+	case "rule", "expand", "parents":
+		id, err := x.ruleArg(arg)
+		if err != nil {
+			return err
+		}
+		switch cmd {
+		case "rule":
+			x.showRule(id)
+		case "expand":
+			fmt.Fprintln(x.w, strconv.Quote(string(x.expansion(id))))
+		case "parents":
+			x.showParents(id)
+		}
+		return nil
+	case "find":
+		if arg == "" {
+			return fmt.Errorf("find what?")
+		}
+		x.find(arg)
+		return nil
+	case "top":
+		k := 10
+		if arg != "" {
+			var err error
+			if k, err = strconv.Atoi(arg); err != nil || k < 1 {
+				return fmt.Errorf("top needs a positive number, not %q", arg)
+			}
+		}
+		x.top(k)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q; type help for the commands", cmd)
+}
+
+// ruleArg gives the rule numbered by arg.
+func (x *explorer) ruleArg(arg string) (sequitur.SymbolID, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 0 || n >= len(x.order) {
+		return 0, fmt.Errorf("no rule %q: rules are numbered 0 to %d", arg, len(x.order)-1)
+	}
+	return x.order[n], nil
+}
+
+func (x *explorer) expansion(id sequitur.SymbolID) []byte {
+	start := x.starts[id][0]
+	return x.input[start : start+x.length[id]]
+}
+
+func (x *explorer) showRule(id sequitur.SymbolID) {
+	fmt.Fprintln(x.w, x.rule(id))
+	starts := x.starts[id]
+	fmt.Fprintf(x.w, "  used %d times, depth %d, height %d, expands to %d bytes, occurs %d times at %s\n",
+		x.d.Rules[id].Used, x.rel.Depth(id), x.rel.Height(id), x.length[id], len(starts), offsets(starts, 10))
+}
+
+// showParents lists the rules using id, with the number of times each uses it.
+func (x *explorer) showParents(id sequitur.SymbolID) {
+	parents := x.rel.Parents(id)
+	sort.Slice(parents, func(i, j int) bool { return x.number[parents[i]] < x.number[parents[j]] })
+	for _, parent := range parents {
+		n := 0
+		for _, sid := range x.d.Rules[parent].IDs {
+			if sid == id {
+				n++
+			}
+		}
+		fmt.Fprintf(x.w, "%s\t(×%d)\n", x.rule(parent), n)
+	}
+	if len(parents) == 0 {
+		fmt.Fprintln(x.w, "no rule uses it")
+	}
+}
+
+// find lists where text occurs in the input, and the smallest rules whose expansions contain it.
+func (x *explorer) find(text string) {
+	var at []int
+	for i := 0; ; {
+		j := bytes.Index(x.input[i:], []byte(text))
+		if j < 0 {
+			break
+		}
+		at = append(at, i+j)
+		i += j + 1
+	}
+	if len(at) == 0 {
+		fmt.Fprintln(x.w, "not found")
+		return
+	}
+	fmt.Fprintf(x.w, "occurs %d times at %s\n", len(at), offsets(at, 10))
+
+	// a rule containing text is only listed if none of the rules it uses does
+	contains := make(map[sequitur.SymbolID]bool)
+	for _, id := range x.order {
+		contains[id] = bytes.Contains(x.expansion(id), []byte(text))
+	}
+	for _, id := range x.order {
+		if !contains[id] {
+			continue
+		}
+		smallest := true
+		for _, sid := range x.d.Rules[id].IDs {
+			if sid.IsRule() && contains[sid] {
+				smallest = false
+				break
+			}
+		}
+		if smallest {
+			fmt.Fprintln(x.w, x.rule(id))
+		}
+	}
+}
+
+// top lists the k rules other than the top-level rule whose occurrences cover the most of the input.
+func (x *explorer) top(k int) {
+	if len(x.order) < 2 {
+		fmt.Fprintln(x.w, "there are no rules")
+		return
+	}
+	rules := append([]sequitur.SymbolID(nil), x.order[1:]...)
+	cover := func(id sequitur.SymbolID) int { return len(x.starts[id]) * x.length[id] }
+	sort.SliceStable(rules, func(i, j int) bool { return cover(rules[i]) > cover(rules[j]) })
+	if len(rules) > k {
+		rules = rules[:k]
+	}
+	for _, id := range rules {
+		fmt.Fprintf(x.w, "%5.1f%% %d×%d %s\n", 100*float64(cover(id))/float64(len(x.input)),
+			len(x.starts[id]), x.length[id], x.rule(id))
+	}
+}
+
+// offsets formats the first max of starts.
+func offsets(starts []int, max int) string {
+	var b strings.Builder
+	for i, s := range starts {
+		if i == max {
+			fmt.Fprintf(&b, " and %d more", len(starts)-max)
+			break
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprint(&b, s)
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExplore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte("abcdbcabcd"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commands := `rules
+rule 1
+expand 2
+parents 2
+find bc
+find zz
+top 1
+rule 3
+
+bogus
+quit
+rules
+`
+	want := `0 -> 1 2 1
+1 -> a 2 d
+2 -> b c
+1 -> a 2 d
+  used 2 times, depth 1, height 2, expands to 4 bytes, occurs 2 times at 0, 6
+"bc"
+0 -> 1 2 1	(×1)
+1 -> a 2 d	(×1)
+occurs 3 times at 1, 4, 7
+2 -> b c
+not found
+ 80.0% 2×4 1 -> a 2 d
+error: no rule "3": rules are numbered 0 to 2
+error: unknown command "bogus"; type help for the commands
+`
+	got, status := runCommand(t, commands, "explore", path)
+	if status != 0 || got != want {
+		t.Errorf("got status %d and\n%s\nwant\n%s", status, got, want)
+	}
+
+	got, _ = runCommand(t, "parents 0\nrule 0\ntop\n", "explore", "-t", "word", path)
+	want = `no rule uses it
+0 -> "abcdbcabcd"
+  used 0 times, depth 0, height 1, expands to 10 bytes, occurs 1 times at 0
+there are no rules
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// the occurrences of rules of tokens are at the offsets of their text
+	if err := os.WriteFile(path, []byte("the cat sat, the cat ran"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = runCommand(t, "rule 1\nexpand 1\n", "explore", "-t", "word", path)
+	want = `1 -> "the" " " "cat" " "
+  used 2 times, depth 1, height 1, expands to 8 bytes, occurs 2 times at 0, 13
+"the cat "
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if _, status := runCommand(t, "", "explore"); status != 1 {
+		t.Error("explore without a file accepted")
+	}
+}
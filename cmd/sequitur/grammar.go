@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+func runGrammar(e *env, args []string) error {
+	fs := e.flags("grammar", "[file]")
+	var in inputFlags
+	in.register(fs)
+	format := fs.String("format", "text", "write the grammar as `text` or json")
+	naming := fs.String("names", "number", "name rules by `scheme`: number, letter or id")
+	order := fs.String("order", "appearance", "write rules in `order`: appearance, id, size or usage")
+	quoting := fs.String("quote", "escaped", "write terminals `escaped` or as strings")
+	var p sequitur.Printer
+	fs.BoolVar(&p.Usage, "usage", false, "note how many times each rule is used")
+	fs.BoolVar(&p.Expansions, "expansions", false, "note the expansion of each rule")
+	fs.IntVar(&p.Width, "width", 0, "wrap lines longer than `n` runes, or never if 0")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	orders := map[string]sequitur.Order{"appearance": sequitur.OrderAppearance, "id": sequitur.OrderID, "size": sequitur.OrderSize, "usage": sequitur.OrderUsage}
+	quotings := map[string]sequitur.Quoting{"escaped": sequitur.QuoteEscaped, "strings": sequitur.QuoteStrings}
+	var ok bool
+	if p.Naming, ok = namings[*naming]; !ok {
+		return fmt.Errorf("unknown naming %q: want number, letter or id", *naming)
+	}
+	if p.Order, ok = orders[*order]; !ok {
+		return fmt.Errorf("unknown order %q: want appearance, id, size or usage", *order)
+	}
+	if p.Quoting, ok = quotings[*quoting]; !ok {
+		return fmt.Errorf("unknown quoting %q: want escaped or strings", *quoting)
+	}
+	d, err := in.load(e, fs)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "text":
+		return d.prettyPrint(e.stdout, p)
+	case "json":
+		return d.save(e.stdout)
+	}
+	return fmt.Errorf("unknown format %q: want text or json", *format)
+}
+
+// namings are the values of -names.
+var namings = map[string]sequitur.Naming{"number": sequitur.NameNumbers, "letter": sequitur.NameLetters, "id": sequitur.NameIDs}
+
+func runCompact(e *env, args []string) error {
+	fs := e.flags("compact", "[file]")
+	var in inputFlags
+	in.register(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	d, err := in.load(e, fs)
+	if err != nil {
+		return err
+	}
+	return d.save(e.stdout)
+}
+
+// prettyPrint writes the rules of the document using p, in the form of PrettyPrint if p is the zero Printer.
+func (d *document) prettyPrint(w io.Writer, p sequitur.Printer) error {
+	return d.printer(p).Print(w, d.compact())
+}
+
+// printer gives p set up for the terminals of the document: tokens are always quoted.
+func (d *document) printer(p sequitur.Printer) *sequitur.Printer {
+	if d.Tokenization != byRune {
+		p.Terminal = func(sid sequitur.SymbolID) string {
+			tok, _ := d.tokens.Token(sid)
+			return strconv.Quote(tok)
+		}
+	}
+	return &p
+}
+
+// save writes the document as JSON, with its rules in canonical order so that the same input always gives the same output.
+func (d *document) save(w io.Writer) error {
+	canonical := *d
+	canonical.setCompact(d.compact().Canonical())
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(&canonical)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+func runHTML(e *env, args []string) error {
+	fs := e.flags("html", "[file]")
+	var in inputFlags
+	in.register(fs)
+	opts := sequitur.HTMLOptions{}
+	fs.StringVar(&opts.Title, "title", "", "the `title` of the page, or the name of the file if empty")
+	fs.IntVar(&opts.MaxDepth, "depth", 0, "mark rules down to depth `n` below the top-level rule, or all of them if 0")
+	naming := fs.String("names", "number", "name rules by `scheme`: number, letter or id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	var ok bool
+	if opts.Naming, ok = namings[*naming]; !ok {
+		return fmt.Errorf("unknown naming %q: want number, letter or id", *naming)
+	}
+	if opts.MaxDepth < 0 {
+		return fmt.Errorf("-depth must not be negative")
+	}
+	d, err := in.load(e, fs)
+	if err != nil {
+		return err
+	}
+	if opts.Title == "" {
+		opts.Title = "standard input"
+		if name := fs.Arg(0); name != "" && name != "-" {
+			opts.Title = name
+		}
+	}
+	if d.Tokenization != byRune {
+		opts.Terminal = func(sid sequitur.SymbolID) string {
+			text, _ := d.terminal(sid)
+			return text
+		}
+	}
+	return sequitur.WriteHTML(e.stdout, d.compact(), opts)
+}
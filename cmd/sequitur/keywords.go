@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+func runKeywords(e *env, args []string) error {
+	flags := e.flags("keywords", "file")
+	n := flags.Int("n", 10, "the number of rule strings to report, or all of them if 0")
+	scoring := flags.String("score", "used", "rank by `method`: coverage, used (coverage×used²), tfidf or bm25")
+	corpusDir := flags.String("corpus", "", "the `dir` of documents to compare the file with, for tfidf and bm25")
+	minLength := flags.Int("min", 1, "the shortest rule string reported, in bytes")
+	maxLength := flags.Int("max", 0, "the longest rule string reported, in bytes, or unlimited if 0")
+	trim := flags.Bool("trim", true, "trim white space from the ends of rule strings")
+	format := flags.String("format", "text", "write the results as `text` or json")
+	workers := flags.Int("j", runtime.GOMAXPROCS(0), "the number of corpus files to parse at once")
+	cacheDir := flags.String("cache", "", "cache grammars in `dir`, keyed by the SHA-256 hash of each file")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q: want text or json", *format)
+	}
+	switch *scoring {
+	case "coverage", "used":
+	case "tfidf", "bm25":
+		if *corpusDir == "" {
+			return fmt.Errorf("-score %s needs -corpus", *scoring)
+		}
+	default:
+		return fmt.Errorf("unknown scoring %q: want coverage, used, tfidf or bm25", *scoring)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("want one file, not %d", flags.NArg())
+	}
+	path := flags.Arg(0)
+
+	keep := sequitur.KeepLength(*minLength, *maxLength, *trim)
+	d, err := loadFile(path, *cacheDir)
+	if err != nil {
+		return err
+	}
+	ci := d.compact().Index(keep)
+
+	var ranked []sequitur.Importance
+	switch *scoring {
+	case "coverage":
+		ranked = ci.Importance(nil)
+	case "used":
+		ranked = ci.Importance(ci.UsedScore)
+	case "tfidf", "bm25":
+		var corpus sequitur.Corpus
+		doc := corpus.Add(ci)
+		if err := addCorpus(&corpus, *corpusDir, path, *cacheDir, *workers, keep); err != nil {
+			return err
+		}
+		weighting := sequitur.TFIDF
+		if *scoring == "bm25" {
+			weighting = sequitur.BM25
+		}
+		ranked = corpus.Importance(doc, weighting)
+	}
+
+	keywords := ci.RuleStrings(ranked, *n, *trim)
+
+	if *format == "json" {
+		return writeJSON(e, keywords)
+	}
+	for i, k := range keywords {
+		if _, err := fmt.Fprintf(e.stdout, "%d %7.5f %q\n", i, k.Score, k.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addCorpus adds every file under dir other than the one at path to the corpus, indexed using keep.
+func addCorpus(corpus *sequitur.Corpus, dir, path, cacheDir string, workers int, keep func([]byte) bool) error {
+	self, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	err = filepath.WalkDir(dir, func(p string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !de.Type().IsRegular() {
+			return nil
+		}
+		if info, err := de.Info(); err != nil || os.SameFile(info, self) {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	docs, err := indexFiles(paths, cacheDir, workers, keep)
+	if err != nil {
+		return err
+	}
+	for _, ci := range docs {
+		corpus.Add(ci)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+func TestKeywords(t *testing.T) {
+	dir := t.TempDir()
+	common := "Copyright the authors. All rights reserved.\n"
+	files := map[string]string{
+		"a.txt": common + strings.Repeat("grammar inference with sequitur; ", 5) + common,
+		"b.txt": common + "something about compression " + common,
+		"c.txt": common + "and something else " + common,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(dir, "a.txt")
+
+	top := func(args ...string) sequitur.RuleString {
+		t.Helper()
+		out, status := runCommand(t, "", append([]string{"keywords", "-format", "json"}, args...)...)
+		if status != 0 {
+			t.Fatalf("keywords %q failed", args)
+		}
+		var keywords []sequitur.RuleString
+		if err := json.Unmarshal([]byte(out), &keywords); err != nil {
+			t.Fatal(err)
+		}
+		if len(keywords) == 0 {
+			t.Fatalf("keywords %q: none found", args)
+		}
+		seen := make(map[string]bool)
+		for _, k := range keywords {
+			if seen[k.String] || strings.TrimSpace(k.String) != k.String {
+				t.Errorf("keywords %q: %q repeated or not trimmed", args, k.String)
+			}
+			seen[k.String] = true
+		}
+		return keywords[0]
+	}
+
+	if k := top("-score", "coverage", a); !strings.HasPrefix(k.String, "grammar inference with sequitur;") {
+		t.Errorf("coverage: got %+v", k)
+	}
+	if k := top("-score", "coverage", "-max", "20", a); len(k.String) > 20 {
+		t.Errorf("coverage with max 20: got %+v", k)
+	}
+	if k := top("-score", "used", "-min", "10", a); len(k.String) < 10 {
+		t.Errorf("used with min 10: got %+v", k)
+	}
+	// the copyright notice is in every file, so it cannot be the most distinctive phrase
+	for _, scoring := range []string{"tfidf", "bm25"} {
+		if k := top("-score", scoring, "-corpus", dir, "-min", "10", a); strings.Contains(k.String, "Copyright") || strings.Contains(k.String, "rights") {
+			t.Errorf("%s: got %+v", scoring, k)
+		}
+	}
+
+	if _, status := runCommand(t, "", "keywords", "-score", "tfidf", a); status != 1 {
+		t.Error("tfidf accepted without a corpus")
+	}
+	if _, status := runCommand(t, "", "keywords", a, a); status != 1 {
+		t.Error("two files accepted")
+	}
+}
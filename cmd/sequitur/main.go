@@ -0,0 +1,134 @@
+// Command sequitur builds and inspects sequitur grammars.
+//
+// Usage:
+//
+//	sequitur command [flags] [file]
+//
+// The commands are:
+//
+//	grammar   print the grammar of a file
+//	compact   save the grammar of a file as JSON
+//	expand    reconstruct the input of a saved grammar
+//	stats     report the size and shape of a grammar
+//	dot       draw the rules of a grammar in Graphviz DOT
+//	html      mark the rules of a grammar on its input in HTML
+//	similar   find files with similar grammars
+//	keywords  rank the repeated phrases of a file
+//	explore   answer questions about the grammar of a file interactively
+//	serve     serve grammar inference over HTTP, as described in package httpapi
+//
+// Input is read from the file, or from standard input if there is none or it is "-".
+// Commands which take an input file accept -t to choose how it is split into terminal symbols:
+// rune (the default) for each UTF-8 character, word for runs of letters and digits, runs of spaces,
+// and single punctuation characters, or line for each line including its newline.
+// They build grammars with Sequitur unless -a repair chooses Re-Pair, and -optimize makes them smaller afterwards.
+// With -g they read a grammar saved by compact instead.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// env is where a command reads and writes.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// errUsage is returned by a command whose flags could not be parsed, the reason having already been reported.
+var errUsage = errors.New("usage")
+
+// flags gives the flag set of a command, which reports errors and usage to stderr.
+func (e *env) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: sequitur %s [flags] %s\n\n%s\n\nflags:\n", name, args, commands[name].summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse the flags of a command, returning errUsage if they are wrong.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+type command struct {
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"grammar":  {"print the grammar of a file", runGrammar},
+		"compact":  {"save the grammar of a file as JSON", runCompact},
+		"expand":   {"reconstruct the input of a saved grammar", runExpand},
+		"stats":    {"report the size and shape of a grammar", runStats},
+		"dot":      {"draw the rules of a grammar in Graphviz DOT", runDot},
+		"html":     {"mark the rules of a grammar on its input in HTML", runHTML},
+		"similar":  {"find files with similar grammars", runSimilar},
+		"keywords": {"rank the repeated phrases of a file", runKeywords},
+		"explore":  {"answer questions about the grammar of a file interactively", runExplore},
+		"serve":    {"serve grammar inference over HTTP", runServe},
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: sequitur command [flags] [file]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nrun 'sequitur command -h' for the flags of a command")
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "sequitur: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(&env{stdin, stdout, stderr}, args[1:]); err != nil {
+		switch err {
+		case flag.ErrHelp:
+			return 0
+		case errUsage:
+			return 2
+		}
+		fmt.Fprintf(stderr, "sequitur %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// runCommand runs a command with stdin, giving its output and exit status.
+func runCommand(t *testing.T, stdin string, args ...string) (string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	if status != 0 {
+		t.Logf("sequitur %s: %s", strings.Join(args, " "), stderr.String())
+	}
+	return stdout.String(), status
+}
+
+const testInput = "The 2 cats (and 2 dogs) sat\tdown.\nThe 2 cats (and 2 dogs) sat\tdown again.\n\xff\xfe\xff\xfe\n"
+
+func TestGrammarMatchesPrettyPrint(t *testing.T) {
+	var want bytes.Buffer
+	if err := sequitur.Parse([]byte(testInput)).PrettyPrint(&want); err != nil {
+		t.Fatal(err)
+	}
+	got, status := runCommand(t, testInput, "grammar")
+	if status != 0 || got != want.String() {
+		t.Errorf("got status %d and\n%s\nwant\n%s", status, got, want.String())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{"sequitur", "repair"} {
+		for _, tokenization := range []string{"rune", "word", "line"} {
+			for _, input := range []string{testInput, "", "a"} {
+				saved, status := runCommand(t, input, "compact", "-t", tokenization, "-a", algorithm)
+				if status != 0 {
+					t.Fatalf("compact -t %s -a %s failed", tokenization, algorithm)
+				}
+				again, _ := runCommand(t, saved, "grammar", "-g", "-format", "json")
+				if again != saved {
+					t.Errorf("-t %s -a %s %q: saved grammar changed when reloaded:\n%s\n%s", tokenization, algorithm, input, saved, again)
+				}
+				if got, status := runCommand(t, saved, "expand"); status != 0 || got != input {
+					t.Errorf("-t %s -a %s: expand gave %q, want %q", tokenization, algorithm, got, input)
+				}
+			}
+		}
+	}
+}
+
+func TestWordGrammar(t *testing.T) {
+	got, _ := runCommand(t, "the cat sat. the cat ran.", "grammar", "-t", "word")
+	want := `0 -> 1 "sat" "." " " 1 "ran" "."
+1 -> "the" " " "cat" " "
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	out, status := runCommand(t, "abcdbcabcd", "stats", "-format", "json")
+	if status != 0 {
+		t.Fatal("stats failed")
+	}
+	var got stats
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := stats{Tokenization: "rune", Bytes: 10, Symbols: 10, Distinct: 4, Rules: 2, RootLength: 3, Size: 8, Ratio: 0.8, Depth: 3}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDot(t *testing.T) {
+	got, _ := runCommand(t, "abcdbcabcd", "dot")
+	want := `digraph grammar {
+	node [shape=box, fontname=monospace];
+	r0 [label="0 -> 1 2 1"];
+	r0 -> r1 [label="×2"];
+	r0 -> r2;
+	r1 [label="1 -> a 2 d"];
+	r1 -> r2;
+	r2 [label="2 -> b c"];
+}
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// labels are escaped for DOT, with the expansion on a line of its own
+	got, _ = runCommand(t, `"a\"a\`, "dot", "-expansions")
+	want = `digraph grammar {
+	node [shape=box, fontname=monospace];
+	r0 [label="0 -> 1 1\n\"\\\"a\\\\\\\"a\\\\\""];
+	r0 -> r1 [label="×2"];
+	r1 [label="1 -> \" a \\\\\n\"\\\"a\\\\\""];
+}
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		stdin string
+		args  []string
+		want  int
+	}{
+		{"", nil, 2},
+		{"", []string{"nonesuch"}, 2},
+		{"", []string{"grammar", "-nonesuch"}, 2},
+		{"abc", []string{"grammar", "-t", "sentence"}, 1},
+		{"abc", []string{"grammar", "-a", "lzw"}, 1},
+		{"abc", []string{"grammar", "-optimize", "speed"}, 1},
+		{"{}", []string{"grammar", "-g", "-a", "repair"}, 2},
+		{"{}", []string{"stats", "-t", "word", "-g"}, 2},
+		{"abc", []string{"grammar", "-format", "yaml"}, 1},
+		{"abc", []string{"grammar", "-names", "roman"}, 1},
+		{"abc", []string{"grammar", "-order", "random"}, 1},
+		{"abc", []string{"html", "-depth", "-1"}, 1},
+		{"abc", []string{"grammar", "a", "b"}, 1},
+		{"", []string{"grammar", "/nonexistent/file"}, 1},
+		{"not json", []string{"expand"}, 1},
+		{`{"tokenization":"rune","root":1114369,"rules":{"1114369":{"IDs":[1114370]},"1114370":{"IDs":[1114369]}}}`, []string{"expand"}, 1},
+		{`{"tokenization":"rune","root":1114369,"rules":{"1114369":{"IDs":[1114371]}}}`, []string{"expand"}, 1},
+		{`{"tokenization":"word","tokens":["a"],"root":1114369,"rules":{"1114369":{"IDs":[57601]}}}`, []string{"expand"}, 1},
+		{"", []string{"help"}, 0},
+		{"", []string{"stats", "-h"}, 0},
+	} {
+		if _, status := runCommand(t, tc.stdin, tc.args...); status != tc.want {
+			t.Errorf("sequitur %q: got status %d, want %d", tc.args, status, tc.want)
+		}
+	}
+}
+
+func TestGrammarPrinter(t *testing.T) {
+	got, _ := runCommand(t, "the cat sat. the cat ran.", "grammar", "-t", "word", "-names", "letter", "-usage", "-expansions")
+	want := `S -> A "sat" "." " " A "ran" "."
+A -> "the" " " "cat" " " (×2 "the" " " "cat" " ")
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	got, _ = runCommand(t, "abcdbcxabcdbcybc", "grammar", "-quote", "strings", "-order", "usage")
+	want = `0 -> 1 "x" 1 "y" 2
+2 -> "bc"
+1 -> "a" 2 "d" 2
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got, status := runCommand(t, "the cat sat. the cat ran.", "html", "-t", "word", "-depth", "1")
+	for _, want := range []string{"<title>standard input</title>", `data-rule="1" style="--h:138" title="rule 1: used 2 times, 2 occurrences">the cat </span>sat`} {
+		if status != 0 || !strings.Contains(got, want) {
+			t.Errorf("status %d, and the page does not contain %s:\n%s", status, want, got)
+		}
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	got, _ := runCommand(t, "abcdbcabcd", "grammar", "-optimize", "symbols")
+	want := "0 -> 1 b c 1\n1 -> a b c d\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	// inlining rule 2 saves nothing, so -strict keeps it
+	got, _ = runCommand(t, "abcdbcabcd", "grammar", "-optimize", "symbols", "-strict")
+	want = "0 -> 1 2 1\n1 -> a 2 d\n2 -> b c\n"
+	if got != want {
+		t.Errorf("-strict: got\n%s\nwant\n%s", got, want)
+	}
+	saved, _ := runCommand(t, testInput, "compact", "-t", "word")
+	got, _ = runCommand(t, saved, "grammar", "-g", "-optimize", "bytes", "-format", "json")
+	if again, _ := runCommand(t, got, "expand"); again != testInput {
+		t.Errorf("optimized grammar expands to %q", again)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dgryski/go-sequitur/httpapi"
+)
+
+func runServe(e *env, args []string) error {
+	flags := e.flags("serve", "")
+	addr := flags.String("addr", "localhost:8080", "listen on `address`")
+	maxBytes := flags.Int64("max-bytes", httpapi.DefaultMaxBytes, "the longest request body accepted, in bytes")
+	maxDocuments := flags.Int("max-documents", httpapi.DefaultMaxDocuments, "the most documents compared by one similarity request")
+	maxCompression := flags.Int64("max-compression", httpapi.DefaultMaxCompression, "the most input parsed by one similarity request for the compression measure, in bytes")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %q", flags.Args())
+	}
+	srv := &http.Server{
+		Addr: *addr,
+		Handler: httpapi.New(httpapi.Options{
+			MaxBytes:       *maxBytes,
+			MaxDocuments:   *maxDocuments,
+			MaxCompression: *maxCompression,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(e.stderr, "sequitur serve: listening on %s\n", *addr)
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	sequitur "github.com/dgryski/go-sequitur"
+	"github.com/dgryski/go-sequitur/cluster"
+)
+
+// similarPair is two files with similar grammars.
+type similarPair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+// similarCluster is a group of files with similar grammars.
+type similarCluster struct {
+	Files  []string `json:"files"`
+	Shared []string `json:"shared,omitempty"` // the rule strings covering most of every file
+}
+
+func runSimilar(e *env, args []string) error {
+	flags := e.flags("similar", "path...")
+	threshold := flags.Float64("threshold", 0.5, "report files with a similarity of at least `s`, from 0 to 1")
+	clustering := flags.String("cluster", "", "report clusters rather than pairs, grouped by `method`: threshold, single, complete or average linkage")
+	shared := flags.Int("shared", 3, "the number of shared rule strings to show for each cluster")
+	format := flags.String("format", "text", "write the results as `text` or json")
+	workers := flags.Int("j", runtime.GOMAXPROCS(0), "the number of files to parse at once")
+	cacheDir := flags.String("cache", "", "cache grammars in `dir`, keyed by the SHA-256 hash of each file")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q: want text or json", *format)
+	}
+	linkages := map[string]cluster.Linkage{"single": cluster.Single, "complete": cluster.Complete, "average": cluster.Average}
+	if _, ok := linkages[*clustering]; !ok && *clustering != "" && *clustering != "threshold" {
+		return fmt.Errorf("unknown clustering %q: want threshold, single, complete or average", *clustering)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no paths given")
+	}
+
+	var paths []string
+	for _, root := range flags.Args() {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sort.Strings(paths)
+	docs, err := indexFiles(paths, *cacheDir, *workers, nil)
+	if err != nil {
+		return err
+	}
+
+	if *clustering == "" {
+		pairs := similarPairs(paths, docs, *threshold, *workers)
+		if *format == "json" {
+			return writeJSON(e, pairs)
+		}
+		for _, p := range pairs {
+			if _, err := fmt.Fprintf(e.stdout, "%.4f\t%s\t%s\n", p.Similarity, p.A, p.B); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var clusters []cluster.Cluster
+	if linkage, ok := linkages[*clustering]; ok {
+		clusters = cluster.Agglomerative(docs, linkage, *workers).Cut(*threshold)
+	} else {
+		clusters = cluster.Threshold(docs, *threshold, *workers)
+	}
+	similar := []similarCluster{}
+	for _, c := range clusters {
+		if len(c.Members) < 2 {
+			continue
+		}
+		sc := similarCluster{}
+		for _, m := range c.Members {
+			sc.Files = append(sc.Files, paths[m])
+		}
+		for i := 0; i < len(c.Shared) && i < *shared; i++ {
+			sc.Shared = append(sc.Shared, c.Shared[i].String)
+		}
+		similar = append(similar, sc)
+	}
+	if *format == "json" {
+		return writeJSON(e, similar)
+	}
+	for i, sc := range similar {
+		if _, err := fmt.Fprintf(e.stdout, "cluster %d: %d files\n", i+1, len(sc.Files)); err != nil {
+			return err
+		}
+		for _, f := range sc.Files {
+			if _, err := fmt.Fprintln(e.stdout, "\t"+f); err != nil {
+				return err
+			}
+		}
+		for _, s := range sc.Shared {
+			if _, err := fmt.Fprintf(e.stdout, "\tshares %q\n", shorten(s, 60)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// similarPairs gives the pairs of docs with a Similarity of at least threshold, most similar first.
+func similarPairs(paths []string, docs []*sequitur.CompactIndexed, threshold float64, workers int) []similarPair {
+	pairs := []similarPair{}
+	for _, p := range sequitur.SimilarPairs(docs, threshold, workers) {
+		pairs = append(pairs, similarPair{A: paths[p.A], B: paths[p.B], Similarity: p.Score})
+	}
+	return pairs
+}
+
+// indexFiles reads and indexes the grammar of each file, keeping the rule strings accepted by keep (all of them if nil),
+// using up to workers goroutines and the grammars cached in cacheDir if it is not empty.
+func indexFiles(paths []string, cacheDir string, workers int, keep func([]byte) bool) ([]*sequitur.CompactIndexed, error) {
+	if cacheDir != "" {
+		if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	docs := make([]*sequitur.CompactIndexed, len(paths))
+	errs := make([]error, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				var d *document
+				if d, errs[i] = loadFile(paths[i], cacheDir); errs[i] == nil {
+					docs[i] = d.compact().Index(keep)
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// loadFile gives the grammar of a file, from cacheDir if it has been cached there.
+func loadFile(path, cacheDir string) (*document, error) {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if cacheDir == "" {
+		return parseDocument(input, byRune, "sequitur")
+	}
+	hash := sha256.Sum256(input)
+	cached := filepath.Join(cacheDir, hex.EncodeToString(hash[:])+".json")
+	if data, err := os.ReadFile(cached); err == nil {
+		if d, err := loadDocument(data); err == nil && d.Tokenization == byRune {
+			return d, nil
+		}
+		// an unreadable cache entry is replaced
+	}
+	d, err := parseDocument(input, byRune, "sequitur")
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := d.save(&b); err != nil {
+		return nil, err
+	}
+	// write to a temporary file first, so that no one sees a partly written cache entry
+	tmp, err := os.CreateTemp(cacheDir, "tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.Write(b.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cached)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return d, nil
+}
+
+// shorten s to at most n runes, marking where it was cut.
+func shorten(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func writeJSON(e *env, v interface{}) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
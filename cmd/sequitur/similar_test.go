@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// randomText gives lines of words chosen from a small vocabulary.
+func randomText(seed uint32, lines int) string {
+	words := strings.Fields("alpha beta gamma delta epsilon zeta eta theta iota kappa lambda mu nu xi omicron pi rho sigma tau")
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		for j := 0; j < 8; j++ {
+			seed = seed*1664525 + 1013904223
+			b.WriteString(words[int(seed>>16)%len(words)])
+			b.WriteByte(" \n"[j/7])
+		}
+	}
+	return b.String()
+}
+
+func TestSimilar(t *testing.T) {
+	dir := t.TempDir()
+	text, other := randomText(1, 40), randomText(2, 40)
+	files := map[string]string{
+		"a.txt":     text,
+		"b.txt":     strings.Replace(text, "alpha", "ALPHA", 2),
+		"sub/c.txt": other,
+		"sub/d.txt": other[:len(other)*9/10],
+		"e.txt":     "something else altogether",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache := filepath.Join(dir, "..", filepath.Base(dir)+"-cache")
+	defer os.RemoveAll(cache)
+
+	for run := 0; run < 2; run++ {
+		out, status := runCommand(t, "", "similar", "-format", "json", "-threshold", "0.15", "-cache", cache, dir)
+		if status != 0 {
+			t.Fatal("similar failed")
+		}
+		var pairs []similarPair
+		if err := json.Unmarshal([]byte(out), &pairs); err != nil {
+			t.Fatal(err)
+		}
+		if len(pairs) != 2 {
+			t.Fatalf("run %d: got pairs %+v, want 2", run, pairs)
+		}
+		for _, p := range pairs {
+			a, b := filepath.Base(p.A), filepath.Base(p.B)
+			if !(a == "a.txt" && b == "b.txt" || a == "c.txt" && b == "d.txt") {
+				t.Errorf("run %d: unexpected pair %+v", run, p)
+			}
+		}
+		if cached, _ := filepath.Glob(filepath.Join(cache, "*.json")); len(cached) != len(files) {
+			t.Errorf("run %d: %d cached grammars, want %d", run, len(cached), len(files))
+		}
+	}
+
+	for _, method := range []string{"threshold", "single", "complete", "average"} {
+		out, status := runCommand(t, "", "similar", "-format", "json", "-threshold", "0.15", "-cluster", method, dir)
+		if status != 0 {
+			t.Fatalf("similar -cluster %s failed", method)
+		}
+		var clusters []similarCluster
+		if err := json.Unmarshal([]byte(out), &clusters); err != nil {
+			t.Fatal(err)
+		}
+		if len(clusters) != 2 || len(clusters[0].Files) != 2 || len(clusters[1].Files) != 2 || len(clusters[0].Shared) == 0 {
+			t.Errorf("-cluster %s: got %+v", method, clusters)
+		}
+	}
+
+	out, _ := runCommand(t, "", "similar", "-cluster", "average", "-threshold", "0.15", dir)
+	if !strings.HasPrefix(out, "cluster 1: 2 files\n\t"+filepath.Join(dir, "a.txt")+"\n") {
+		t.Errorf("got text output\n%s", out)
+	}
+	if _, status := runCommand(t, "", "similar", "-cluster", "kmeans", dir); status != 1 {
+		t.Errorf("unknown clustering accepted")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import "fmt"
+
+// stats describes the size and shape of a grammar.
+type stats struct {
+	Tokenization string  `json:"tokenization"`
+	Bytes        int     `json:"bytes"`       // the length of the input
+	Symbols      int     `json:"symbols"`     // the number of terminals in the input
+	Distinct     int     `json:"distinct"`    // the number of different terminals
+	Rules        int     `json:"rules"`       // not counting the top-level rule
+	RootLength   int     `json:"root_length"` // the number of symbols in the top-level rule
+	Size         int     `json:"size"`        // the number of symbols in all of the rules
+	Ratio        float64 `json:"ratio"`       // Size divided by Symbols
+	Depth        int     `json:"depth"`       // the deepest nesting of rules, 1 if the top-level rule uses no others
+}
+
+func runStats(e *env, args []string) error {
+	fs := e.flags("stats", "[file]")
+	var in inputFlags
+	in.register(fs)
+	format := fs.String("format", "text", "write the statistics as `text` or json")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q: want text or json", *format)
+	}
+	d, err := in.load(e, fs)
+	if err != nil {
+		return err
+	}
+	s := d.stats()
+	if *format == "json" {
+		return writeJSON(e, s)
+	}
+	_, err = fmt.Fprintf(e.stdout, `tokenization %s
+bytes        %d
+symbols      %d
+distinct     %d
+rules        %d
+root length  %d
+size         %d
+ratio        %.4f
+depth        %d
+`, s.Tokenization, s.Bytes, s.Symbols, s.Distinct, s.Rules, s.RootLength, s.Size, s.Ratio, s.Depth)
+	return err
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter int
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+func (d *document) stats() stats {
+	shape := d.compact().Shape()
+	s := stats{
+		Tokenization: d.Tokenization,
+		Symbols:      shape.Symbols,
+		Distinct:     shape.Distinct,
+		Rules:        shape.Rules,
+		RootLength:   shape.RootLength,
+		Size:         shape.Size,
+		Ratio:        shape.Ratio,
+		Depth:        shape.Depth,
+	}
+	var n countingWriter
+	_ = d.expand(&n) // writing to a countingWriter cannot fail
+	s.Bytes = int(n)
+	return s
+}
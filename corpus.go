@@ -0,0 +1,126 @@
+package sequitur
+
+import (
+	"math"
+	"sort"
+)
+
+// Weighting selects how Corpus.Importance scores the rule strings of a document.
+type Weighting int
+
+const (
+	// TFIDF scores a rule string by its number of occurrences in the document,
+	// times the log of the number of documents divided by the number containing the string.
+	TFIDF Weighting = iota
+
+	// BM25 scores a rule string using Okapi BM25 with k1=1.2 and b=0.75,
+	// taking OriginalInputLength as the length of each document.
+	BM25
+)
+
+// Corpus tracks how many documents contain each rule string, so that the strings which distinguish a document
+// can be told apart from those common to many of them. The zero value is ready to use.
+type Corpus struct {
+	docs        []*CompactIndexed
+	terms       []map[string]term // the rule strings of each document
+	df          map[string]int    // the number of documents containing each rule string
+	totalLength int
+}
+
+// Add a document to the corpus, giving its index.
+func (c *Corpus) Add(ci *CompactIndexed) int {
+	if c.df == nil {
+		c.df = make(map[string]int)
+	}
+	var terms map[string]term
+	if ci != nil {
+		terms = ci.terms()
+		for str := range ci.StringToID {
+			c.df[str]++
+		}
+		c.totalLength += ci.OriginalInputLength
+	}
+	c.docs = append(c.docs, ci)
+	c.terms = append(c.terms, terms)
+	return len(c.docs) - 1
+}
+
+// term is a rule string of a document.
+type term struct {
+	id    SymbolID // the lowest of the rules with the string as their expansion
+	used  int      // the sum of the CompactEntry.Used of those rules
+	count int      // the number of occurrences of those rules in the input
+}
+
+// terms gives the rule strings of the index, taking together all of the rules with the same expansion,
+// so that it does not matter which of them StringToID holds.
+func (ci *CompactIndexed) terms() map[string]term {
+	comp := ci.CompactBasis
+	keys := comp.expansionKeys()
+	counts := comp.occurrenceCounts()
+	byKey := make(map[expansionKey]term)
+	for sid := range ci.IDinfo {
+		t, ok := byKey[keys[sid]]
+		if !ok || sid < t.id {
+			t.id = sid
+		}
+		t.used += comp.Map[sid].Used
+		t.count += counts[sid]
+		byKey[keys[sid]] = t
+	}
+	terms := make(map[string]term, len(ci.StringToID))
+	for str, sid := range ci.StringToID {
+		terms[str] = byKey[keys[sid]]
+	}
+	return terms
+}
+
+// Len gives the number of documents in the corpus.
+func (c *Corpus) Len() int {
+	return len(c.docs)
+}
+
+// DocumentFrequency gives the number of documents in the corpus containing the rule string str.
+func (c *Corpus) DocumentFrequency(str string) int {
+	return c.df[str]
+}
+
+// Importance ranks the rule strings of document doc (as given by Add) according to the given weighting,
+// most important first, in the same form as CompactIndexed.Importance.
+func (c *Corpus) Importance(doc int, w Weighting) []Importance {
+	if doc < 0 || doc >= len(c.docs) || c.docs[doc] == nil {
+		return nil
+	}
+	ci, terms := c.docs[doc], c.terms[doc]
+	n := float64(len(c.docs))
+	avgLength := float64(c.totalLength) / n
+
+	const k1, b = 1.2, 0.75
+	imp := make([]Importance, 0, len(terms))
+	for str, t := range terms {
+		tf, df := float64(t.count), float64(c.df[str])
+		var score float64
+		switch w {
+		case TFIDF:
+			score = tf * math.Log(n/df)
+		case BM25:
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1.0
+			if avgLength > 0 {
+				norm = 1 - b + b*float64(ci.OriginalInputLength)/avgLength
+			}
+			score = idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+		imp = append(imp, Importance{
+			ID:    t.id,
+			Score: score,
+		})
+	}
+	sort.Slice(imp, func(i, j int) bool {
+		if imp[i].Score == imp[j].Score {
+			return imp[i].ID > imp[j].ID // arbritrary but stable order
+		}
+		return imp[i].Score > imp[j].Score
+	})
+	return imp
+}
@@ -0,0 +1,77 @@
+package sequitur
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func ExampleCorpus_Importance() {
+
+	filter := func(in []byte) bool {
+		in = bytes.TrimSpace(in)
+		return len(in) >= 5 && len(in) <= 25
+	}
+
+	var corpus Corpus
+	for _, text := range []string{testSimilarity, testString, testCompact} {
+		corpus.Add(Parse([]byte(text)).Compact().Index(filter))
+	}
+	wikipedia := Parse([]byte(testImportance)).Compact().Index(filter)
+	doc := corpus.Add(wikipedia)
+
+	for k, v := range corpus.Importance(doc, BM25) {
+		if k >= 10 {
+			break
+		}
+		fmt.Printf("%d %7.5f %s\n", k, v.Score, string(bytes.TrimSpace(wikipedia.CompactBasis.Bytes(v.ID))))
+	}
+
+	// Output:
+	// 0 2.15966 ymbol
+	// 1 2.15966 grammar
+	// 2 2.01112 gorithm
+	// 3 2.01112 igram
+	// 4 1.95873 algorithm
+	// 5 1.89695 sequenc
+	// 6 1.82304 inal s
+	// 7 1.82304 in the
+	// 8 1.82304 digram
+	// 9 1.82304 symbol
+}
+
+func TestCorpus(t *testing.T) {
+	var corpus Corpus
+	if corpus.Importance(0, TFIDF) != nil {
+		t.Error("empty corpus has importance")
+	}
+	texts := []string{"the cat sat on the mat, the end", "the dog sat on the log, the end", "the cat ate the rat, the end"}
+	for i, text := range texts {
+		if doc := corpus.Add(Parse([]byte(text)).Compact().Index(nil)); doc != i {
+			t.Errorf("document %d added as %d", i, doc)
+		}
+	}
+	if corpus.Len() != len(texts) {
+		t.Errorf("Len() = %d, want %d", corpus.Len(), len(texts))
+	}
+	if df := corpus.DocumentFrequency("the "); df != 3 {
+		t.Errorf("DocumentFrequency(%q) = %d, want 3", "the ", df)
+	}
+	for _, w := range []Weighting{TFIDF, BM25} {
+		for doc := range texts {
+			imp := corpus.Importance(doc, w)
+			if len(imp) == 0 {
+				t.Fatalf("document %d has no important strings", doc)
+			}
+			for i, v := range imp {
+				if i > 0 && imp[i-1].Score < v.Score {
+					t.Errorf("weighting %d document %d not in order", w, doc)
+				}
+			}
+			ci := corpus.docs[doc]
+			if sid := ci.StringToID["the "]; imp[0].ID == sid {
+				t.Errorf("weighting %d document %d ranks a common string first", w, doc)
+			}
+		}
+	}
+}
@@ -0,0 +1,638 @@
+package sequitur
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"unicode/utf8"
+)
+
+// EditOp is the kind of an Edit.
+type EditOp int
+
+const (
+	Shared   EditOp = iota // the block is in both inputs
+	Deleted                // the block is only in the first input
+	Inserted               // the block is only in the second input
+)
+
+// String for EditOp.
+func (op EditOp) String() string {
+	switch op {
+	case Shared:
+		return "shared"
+	case Deleted:
+		return "deleted"
+	case Inserted:
+		return "inserted"
+	}
+	return "unknown"
+}
+
+// Edit is one block of an edit script turning the input of one grammar into the input of another.
+type Edit struct {
+	Op     EditOp
+	AStart int // byte offset of the block in the first input, or where it is inserted
+	AEnd   int // byte offset just after the block in the first input, equal to AStart for Inserted blocks
+	BStart int // byte offset of the block in the second input, or where it was deleted
+	BEnd   int // byte offset just after the block in the second input, equal to BStart for Deleted blocks
+}
+
+// expansionKey identifies the expansion of a symbol by its length and a hash which does not depend on the
+// structure of the rules, so that identical expansions have identical keys in different grammars.
+type expansionKey struct {
+	hash uint64
+	n    int
+}
+
+// polynomial hashing modulo the Mersenne prime 2^61-1
+const (
+	hashMod  = 1<<61 - 1
+	hashBase = 1000003
+)
+
+func mulMod(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	r := (lo & hashMod) + (lo>>61 | hi<<3)
+	if r >= hashMod {
+		r -= hashMod
+	}
+	return r
+}
+
+func powMod(b uint64, e int) uint64 {
+	r := uint64(1)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = mulMod(r, b)
+		}
+		b = mulMod(b, b)
+	}
+	return r
+}
+
+// concat gives the key of the expansion of x followed by that of y.
+func (x expansionKey) concat(y expansionKey) expansionKey {
+	h := mulMod(x.hash, powMod(hashBase, y.n)) + y.hash
+	if h >= hashMod {
+		h -= hashMod
+	}
+	return expansionKey{hash: h, n: x.n + y.n}
+}
+
+// expansionKeys gives the key of every symbol of a grammar.
+func (comp *Compact) expansionKeys() map[SymbolID]expansionKey {
+	keys := make(map[SymbolID]expansionKey)
+	var key func(id SymbolID) expansionKey
+	key = func(id SymbolID) expansionKey {
+		if k, ok := keys[id]; ok {
+			return k
+		}
+		var k expansionKey
+		if id.IsRule() {
+			for _, sid := range comp.Map[id].IDs {
+				k = k.concat(key(sid))
+			}
+		} else {
+			for _, b := range id.Bytes(comp) {
+				k = k.concat(expansionKey{hash: uint64(b) + 1, n: 1})
+			}
+		}
+		keys[id] = k
+		return k
+	}
+	key(comp.RootID)
+	return keys
+}
+
+// diffNode is a symbol in the input of a grammar.
+type diffNode struct {
+	id  SymbolID
+	key expansionKey
+}
+
+type differ struct {
+	a, b           *Compact
+	aKeys, bKeys   map[SymbolID]expansionKey
+	aBytes, bBytes []byte // the inputs, to check that blocks with the same keys really are the same
+	edits          []Edit
+	aPos, bPos     int
+}
+
+func (d *differ) emit(op EditOp, an, bn int) {
+	e := Edit{Op: op, AStart: d.aPos, AEnd: d.aPos + an, BStart: d.bPos, BEnd: d.bPos + bn}
+	d.aPos, d.bPos = e.AEnd, e.BEnd
+	if e.AStart == e.AEnd && e.BStart == e.BEnd {
+		return
+	}
+	if n := len(d.edits); n > 0 && d.edits[n-1].Op == op {
+		d.edits[n-1].AEnd, d.edits[n-1].BEnd = e.AEnd, e.BEnd
+		return
+	}
+	d.edits = append(d.edits, e)
+}
+
+func nodesLength(nodes []diffNode) int {
+	n := 0
+	for _, node := range nodes {
+		n += node.key.n
+	}
+	return n
+}
+
+// expandNodes replaces each rule in nodes by the symbols it consists of.
+func expandNodes(comp *Compact, keys map[SymbolID]expansionKey, nodes []diffNode) ([]diffNode, bool) {
+	var ret []diffNode
+	expanded := false
+	for _, node := range nodes {
+		if !node.id.IsRule() {
+			ret = append(ret, node)
+			continue
+		}
+		expanded = true
+		for _, sid := range comp.Map[node.id].IDs {
+			ret = append(ret, diffNode{id: sid, key: keys[sid]})
+		}
+	}
+	return ret, expanded
+}
+
+func (d *differ) diff(as, bs []diffNode) {
+	for len(as) > 0 && len(bs) > 0 && as[0].key == bs[0].key {
+		d.shared(as[0].key.n, bs[0].key.n)
+		as, bs = as[1:], bs[1:]
+	}
+	var suffix int
+	for len(as) > 0 && len(bs) > 0 && as[len(as)-1].key == bs[len(bs)-1].key {
+		suffix += as[len(as)-1].key.n
+		as, bs = as[:len(as)-1], bs[:len(bs)-1]
+	}
+
+	i, j := 0, 0
+	if len(as) > 0 && len(bs) > 0 {
+		pairs, ok := lcs(len(as), len(bs), func(i, j int) bool { return as[i].key == bs[j].key })
+		if !ok {
+			// too many differences to compare at once, so split the nodes at those found once on each side, and compare the parts
+			if anchors := uniqueAnchors(as, bs); len(anchors) > 0 {
+				for _, p := range anchors {
+					d.diff(as[i:p[0]], bs[j:p[1]])
+					d.shared(as[p[0]].key.n, bs[p[1]].key.n)
+					i, j = p[0]+1, p[1]+1
+				}
+				d.diff(as[i:], bs[j:])
+				d.shared(suffix, suffix)
+				return
+			}
+		}
+		for _, p := range pairs {
+			d.gap(as[i:p[0]], bs[j:p[1]])
+			d.shared(as[p[0]].key.n, bs[p[1]].key.n)
+			i, j = p[0]+1, p[1]+1
+		}
+	}
+	d.gap(as[i:], bs[j:])
+	d.shared(suffix, suffix)
+}
+
+// uniqueAnchors gives the pairs of nodes whose keys occur just once in as and once in bs, keeping the longest run of
+// them which is in the same order on both sides, as patience diff does with unique lines.
+func uniqueAnchors(as, bs []diffNode) [][2]int {
+	const twice = -1
+	inA := make(map[expansionKey]int)
+	for i, node := range as {
+		if _, seen := inA[node.key]; seen {
+			inA[node.key] = twice
+		} else {
+			inA[node.key] = i
+		}
+	}
+	inB := make(map[expansionKey]int)
+	for j, node := range bs {
+		if i, ok := inA[node.key]; !ok || i == twice {
+			continue
+		}
+		if _, seen := inB[node.key]; seen {
+			inB[node.key] = twice
+		} else {
+			inB[node.key] = j
+		}
+	}
+	var candidates [][2]int // in the order of bs
+	for j, node := range bs {
+		if k, ok := inB[node.key]; ok && k == j {
+			candidates = append(candidates, [2]int{inA[node.key], j})
+		}
+	}
+
+	// the longest increasing subsequence of the indexes in as, by patience sorting
+	var tops []int                       // the index in candidates of the top of each pile
+	prev := make([]int, len(candidates)) // the top of the pile to the left when each candidate was placed
+	for c, p := range candidates {
+		pile := sort.Search(len(tops), func(k int) bool { return candidates[tops[k]][0] > p[0] })
+		prev[c] = -1
+		if pile > 0 {
+			prev[c] = tops[pile-1]
+		}
+		if pile == len(tops) {
+			tops = append(tops, c)
+		} else {
+			tops[pile] = c
+		}
+	}
+	anchors := make([][2]int, len(tops))
+	for k, c := len(tops)-1, -1; k >= 0; k-- {
+		if c == -1 {
+			c = tops[k]
+		} else {
+			c = prev[c]
+		}
+		anchors[k] = candidates[c]
+	}
+	return anchors
+}
+
+// shared emits a block which the keys of its expansions say is shared, unless its bytes differ after all.
+func (d *differ) shared(an, bn int) {
+	if an == bn && bytes.Equal(d.aBytes[d.aPos:d.aPos+an], d.bBytes[d.bPos:d.bPos+bn]) {
+		d.emit(Shared, an, bn)
+		return
+	}
+	d.emit(Deleted, an, 0)
+	d.emit(Inserted, 0, bn)
+}
+
+// gap handles a run of unmatched nodes, comparing the symbols within them if there are any.
+func (d *differ) gap(as, bs []diffNode) {
+	if len(as) > 0 && len(bs) > 0 {
+		ea, expandedA := expandNodes(d.a, d.aKeys, as)
+		eb, expandedB := expandNodes(d.b, d.bKeys, bs)
+		if expandedA || expandedB {
+			d.diff(ea, eb)
+			return
+		}
+	}
+	d.emit(Deleted, nodesLength(as), 0)
+	d.emit(Inserted, 0, nodesLength(bs))
+}
+
+// lcsBudget bounds the work of each call of lcs, as the product of the lengths of the sequences and the number
+// of differences it searches for, so that long sequences with many differences are given up on quickly.
+const lcsBudget = 1 << 24
+
+// minLCSDifferences is the fewest differences lcs will search for, however long the sequences.
+const minLCSDifferences = 64
+
+// lcs finds a longest common subsequence of two sequences of lengths n and m using the linear space version of
+// Myers' O(ND) algorithm, giving the indexes of the matching pairs in ascending order. It gives up, returning false,
+// if the sequences differ in more places than the budget allows.
+func lcs(n, m int, eq func(i, j int) bool) ([][2]int, bool) {
+	maxD := minLCSDifferences
+	if n+m > 0 && lcsBudget/(n+m) > maxD {
+		maxD = lcsBudget / (n + m)
+	}
+	s := &lcsSearch{eq: eq, maxD: maxD}
+	if !s.compare(0, n, 0, m) {
+		return nil, false
+	}
+	return s.pairs, true
+}
+
+// lcsSearch finds the matching pairs of a pair of sequences, dividing them around the middle of a shortest edit path.
+type lcsSearch struct {
+	eq     func(i, j int) bool
+	maxD   int
+	pairs  [][2]int
+	v1, v2 []int
+}
+
+// compare finds the matching pairs of a[a0:a1] and b[b0:b1], returning false if there are too many differences.
+func (s *lcsSearch) compare(a0, a1, b0, b1 int) bool {
+	for a0 < a1 && b0 < b1 && s.eq(a0, b0) {
+		s.pairs = append(s.pairs, [2]int{a0, b0})
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1 && b0 < b1 && s.eq(a1-1, b1-1) {
+		a1--
+		b1--
+		suffix++
+	}
+	if a0 < a1 && b0 < b1 {
+		x, y, found, capped := s.bisect(a0, a1, b0, b1)
+		if !found && capped || found && (!s.compare(a0, x, b0, y) || !s.compare(x, a1, y, b1)) {
+			return false
+		}
+	}
+	for i := 0; i < suffix; i++ {
+		s.pairs = append(s.pairs, [2]int{a1 + i, b1 + i})
+	}
+	return true
+}
+
+// bisect finds a point on a shortest edit path from a0, b0 to a1, b1 where the paths searched forwards from the start
+// and backwards from the end meet, as in the diff-match-patch library. If there is none, either the sequences have
+// nothing in common, or the search was capped at maxD differences.
+func (s *lcsSearch) bisect(a0, a1, b0, b1 int) (x, y int, found, capped bool) {
+	n, m := a1-a0, b1-b0
+	maxD := (n + m + 1) / 2
+	if limit := s.maxD/2 + 1; maxD > limit {
+		maxD, capped = limit, true
+	}
+	offset, length := maxD, 2*maxD+2
+	if cap(s.v1) < length {
+		s.v1, s.v2 = make([]int, length), make([]int, length)
+	}
+	v1, v2 := s.v1[:length], s.v2[:length]
+	for i := range v1 {
+		v1[i], v2[i] = -1, -1
+	}
+	v1[offset+1], v2[offset+1] = 0, 0
+	delta := n - m
+	front := delta%2 != 0 // whether the forward path meets the backward one, rather than the other way round
+	k1start, k1end, k2start, k2end := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k1 := -d + k1start; k1 <= d-k1end; k1 += 2 {
+			i := offset + k1
+			var x1 int
+			if k1 == -d || k1 != d && v1[i-1] < v1[i+1] {
+				x1 = v1[i+1]
+			} else {
+				x1 = v1[i-1] + 1
+			}
+			y1 := x1 - k1
+			for x1 < n && y1 < m && s.eq(a0+x1, b0+y1) {
+				x1++
+				y1++
+			}
+			v1[i] = x1
+			switch {
+			case x1 > n:
+				k1end += 2 // off the right of the grid
+			case y1 > m:
+				k1start += 2 // off the bottom of the grid
+			case front:
+				if j := offset + delta - k1; j >= 0 && j < length && v2[j] != -1 && x1 >= n-v2[j] {
+					return a0 + x1, b0 + y1, true, capped
+				}
+			}
+		}
+		for k2 := -d + k2start; k2 <= d-k2end; k2 += 2 {
+			i := offset + k2
+			var x2 int
+			if k2 == -d || k2 != d && v2[i-1] < v2[i+1] {
+				x2 = v2[i+1]
+			} else {
+				x2 = v2[i-1] + 1
+			}
+			y2 := x2 - k2
+			for x2 < n && y2 < m && s.eq(a1-x2-1, b1-y2-1) {
+				x2++
+				y2++
+			}
+			v2[i] = x2
+			switch {
+			case x2 > n:
+				k2end += 2
+			case y2 > m:
+				k2start += 2
+			case !front:
+				if j := offset + delta - k2; j >= 0 && j < length && v1[j] != -1 {
+					x1 := v1[j]
+					if y1 := offset + x1 - j; x1 >= n-x2 {
+						return a0 + x1, b0 + y1, true, capped
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false, capped
+}
+
+// Diff gives the edit script turning the input of a into the input of b. Symbols are compared by their expansions,
+// so long shared runs are matched as whole rules, and only rules which differ are broken down further. As matching
+// whole rules can pair up the wrong repetitions, changes which are close together are then compared rune by rune.
+// Finally short shared blocks between changes are folded into them, so the script is not necessarily minimal.
+// Runs of symbols which differ in too many places to compare quickly are split at the symbols found just once in each,
+// as patience diff splits at unique lines, and the parts compared in turn. Runs with no such symbols, and runs of runes
+// which differ in too many places, are given as a single change, so that the time taken grows with the size of the inputs
+// rather than its square.
+func Diff(a, b *Compact) []Edit {
+	d := &differ{a: a, b: b}
+	roots := func(comp *Compact) ([]diffNode, map[SymbolID]expansionKey, []byte) {
+		if comp == nil || comp.RootID == EmptySymbolID {
+			return nil, nil, nil
+		}
+		keys := comp.expansionKeys()
+		return []diffNode{{id: comp.RootID, key: keys[comp.RootID]}}, keys, comp.Bytes(comp.RootID)
+	}
+	as, aKeys, ab := roots(a)
+	bs, bKeys, bb := roots(b)
+	d.aKeys, d.bKeys = aKeys, bKeys
+	d.aBytes, d.bBytes = ab, bb
+	d.diff(as, bs)
+	return cleanupEdits(refineEdits(ab, bb, d.edits))
+}
+
+// change is a run of non-shared edits, or a shared block.
+type change struct{ aStart, aEnd, bStart, bEnd int }
+
+func (c change) size() int {
+	if a, b := c.aEnd-c.aStart, c.bEnd-c.bStart; a > b {
+		return a
+	}
+	return c.bEnd - c.bStart
+}
+
+// changeParts gives alternating changes (perhaps empty) and shared blocks, with a change at each even index.
+func changeParts(edits []Edit) []change {
+	var parts []change
+	for _, e := range edits {
+		if len(parts)%2 == 0 {
+			parts = append(parts, change{e.AStart, e.AStart, e.BStart, e.BStart})
+		}
+		if e.Op == Shared {
+			parts = append(parts, change{e.AStart, e.AEnd, e.BStart, e.BEnd})
+			continue
+		}
+		c := &parts[len(parts)-1]
+		c.aEnd, c.bEnd = e.AEnd, e.BEnd
+	}
+	return parts
+}
+
+// foldable says if the shared block at parts[i] is no longer than the changes on either side of it.
+func foldable(parts []change, i int) bool {
+	n := parts[i].aEnd - parts[i].aStart
+	return parts[i-1].size() > 0 && n <= parts[i-1].size() && n <= parts[i+1].size()
+}
+
+// maxRefine is the largest region, in bytes of both inputs, which refineEdits compares rune by rune.
+const maxRefine = 1 << 16
+
+// refineEdits merges changes separated by short shared blocks into regions, and compares each region rune by rune,
+// unless it is larger than maxRefine or differs too much for lcs, when it is left as a single change.
+func refineEdits(a, b []byte, edits []Edit) []Edit {
+	parts := changeParts(edits)
+	for folded := true; folded; {
+		folded = false
+		for i := 1; i+1 < len(parts); i += 2 {
+			if foldable(parts, i) {
+				parts[i-1].aEnd, parts[i-1].bEnd = parts[i+1].aEnd, parts[i+1].bEnd
+				parts = append(parts[:i], parts[i+2:]...)
+				folded = true
+			}
+		}
+	}
+
+	d := &differ{}
+	for i, p := range parts {
+		if i%2 == 1 {
+			d.emit(Shared, p.aEnd-p.aStart, p.bEnd-p.bStart)
+			continue
+		}
+		if p.aEnd-p.aStart+p.bEnd-p.bStart > maxRefine {
+			d.emit(Deleted, p.aEnd-p.aStart, 0)
+			d.emit(Inserted, 0, p.bEnd-p.bStart)
+			continue
+		}
+		as, bs := terminals(a[p.aStart:p.aEnd]), terminals(b[p.bStart:p.bEnd])
+		j, k := 0, 0
+		if len(as) > 0 && len(bs) > 0 {
+			pairs, _ := lcs(len(as), len(bs), func(j, k int) bool { return as[j] == bs[k] })
+			for _, m := range pairs {
+				d.emit(Deleted, nodesLength(as[j:m[0]]), 0)
+				d.emit(Inserted, 0, nodesLength(bs[k:m[1]]))
+				d.emit(Shared, as[m[0]].key.n, bs[m[1]].key.n)
+				j, k = m[0]+1, m[1]+1
+			}
+		}
+		d.emit(Deleted, nodesLength(as[j:]), 0)
+		d.emit(Inserted, 0, nodesLength(bs[k:]))
+	}
+	return d.edits
+}
+
+// terminals splits input into the terminal symbols Parse would give it.
+func terminals(input []byte) []diffNode {
+	var ret []diffNode
+	for off := 0; off < len(input); {
+		var rb runeOrByte
+		r, sz := utf8.DecodeRune(input[off:])
+		if sz == 1 && r == utf8.RuneError {
+			rb = newByte(input[off])
+		} else {
+			rb = newRune(r)
+		}
+		ret = append(ret, diffNode{id: SymbolID(rb), key: expansionKey{hash: uint64(rb), n: sz}})
+		off += sz
+	}
+	return ret
+}
+
+// cleanupEdits folds each shared block no longer than the changes on either side of it into those changes,
+// so that a change is not split up by short runs which happen to be equal, like "d" and "g" in "porridge" and "pudding".
+func cleanupEdits(edits []Edit) []Edit {
+	parts := changeParts(edits)
+
+	// decide on the original sizes of the changes, so that folding does not cascade
+	fold := make([]bool, len(parts))
+	for i := 1; i+1 < len(parts); i += 2 {
+		fold[i] = foldable(parts, i)
+	}
+	merged := parts[:0]
+	for i := 0; i < len(parts); i++ {
+		if fold[i] {
+			merged[len(merged)-1].aEnd, merged[len(merged)-1].bEnd = parts[i+1].aEnd, parts[i+1].bEnd
+			i++
+			continue
+		}
+		merged = append(merged, parts[i])
+	}
+
+	ret := edits[:0]
+	for i, p := range merged {
+		if i%2 == 1 {
+			ret = append(ret, Edit{Op: Shared, AStart: p.aStart, AEnd: p.aEnd, BStart: p.bStart, BEnd: p.bEnd})
+			continue
+		}
+		if p.aEnd > p.aStart {
+			ret = append(ret, Edit{Op: Deleted, AStart: p.aStart, AEnd: p.aEnd, BStart: p.bStart, BEnd: p.bStart})
+		}
+		if p.bEnd > p.bStart {
+			ret = append(ret, Edit{Op: Inserted, AStart: p.aEnd, AEnd: p.aEnd, BStart: p.bStart, BEnd: p.bEnd})
+		}
+	}
+	return ret
+}
+
+// WriteDiff renders an edit script from Diff as text resembling a unified diff: each hunk of changes starts with
+// a header giving the byte offsets and lengths of the changed blocks, followed by up to context lines of shared text,
+// the lines deleted from a prefixed with "-", the lines inserted from b prefixed with "+", and up to context
+// lines of shared text. Blocks do not have to start or end at line boundaries.
+func WriteDiff(w io.Writer, a, b *Compact, edits []Edit, context int) error {
+	var ab, bb []byte
+	if a != nil {
+		ab = a.Bytes(a.RootID)
+	}
+	if b != nil {
+		bb = b.Bytes(b.RootID)
+	}
+	bw := bufio.NewWriter(w)
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Shared {
+			i++
+			continue
+		}
+		first := i
+		for i < len(edits) && edits[i].Op != Shared {
+			i++
+		}
+		changes := edits[first:i]
+		fmt.Fprintf(bw, "@@ -%d,%d +%d,%d @@\n",
+			changes[0].AStart, changes[len(changes)-1].AEnd-changes[0].AStart,
+			changes[0].BStart, changes[len(changes)-1].BEnd-changes[0].BStart)
+		if first > 0 {
+			before := edits[first-1]
+			lines := splitLines(ab[before.AStart:before.AEnd])
+			if len(lines) > context {
+				lines = lines[len(lines)-context:]
+			}
+			writeLines(bw, ' ', lines)
+		}
+		for _, e := range changes {
+			if e.Op == Deleted {
+				writeLines(bw, '-', splitLines(ab[e.AStart:e.AEnd]))
+			} else {
+				writeLines(bw, '+', splitLines(bb[e.BStart:e.BEnd]))
+			}
+		}
+		if i < len(edits) {
+			after := edits[i]
+			lines := splitLines(ab[after.AStart:after.AEnd])
+			if len(lines) > context {
+				lines = lines[:context]
+			}
+			writeLines(bw, ' ', lines)
+		}
+	}
+	return bw.Flush()
+}
+
+func splitLines(b []byte) [][]byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+}
+
+func writeLines(w *bufio.Writer, prefix byte, lines [][]byte) {
+	for _, line := range lines {
+		w.WriteByte(prefix)
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+}
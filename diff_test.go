@@ -0,0 +1,265 @@
+package sequitur
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+)
+
+func ExampleWriteDiff() {
+
+	a := Parse([]byte("pease porridge hot,\npease porridge cold,\npease porridge in the pot,\nnine days old.\n")).Compact()
+	b := Parse([]byte("pease pudding hot,\npease pudding cold,\npease pudding in the pot,\nnine days old.\n")).Compact()
+
+	if err := WriteDiff(os.Stdout, a, b, Diff(a, b), 1); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// @@ -7,7 +7,6 @@
+	//  pease p
+	// -orridge
+	// +udding
+	//   hot,
+	// @@ -27,7 +26,6 @@
+	//  pease p
+	// -orridge
+	// +udding
+	//   cold,
+	// @@ -48,7 +46,6 @@
+	//  pease p
+	// -orridge
+	// +udding
+	//   in the pot,
+}
+
+// checkDiff checks that the edits account for all of both inputs, in order, and that shared blocks are equal.
+func checkDiff(t *testing.T, a, b []byte, edits []Edit) {
+	t.Helper()
+	var ra, rb []byte
+	aPos, bPos := 0, 0
+	for i, e := range edits {
+		if e.AStart != aPos || e.BStart != bPos {
+			t.Fatalf("edit %d %+v does not follow on from %d, %d", i, e, aPos, bPos)
+		}
+		switch e.Op {
+		case Shared:
+			if !bytes.Equal(a[e.AStart:e.AEnd], b[e.BStart:e.BEnd]) {
+				t.Errorf("shared block %+v differs: %q %q", e, a[e.AStart:e.AEnd], b[e.BStart:e.BEnd])
+			}
+		case Deleted:
+			if e.BStart != e.BEnd {
+				t.Errorf("deleted block %+v has a length in b", e)
+			}
+		case Inserted:
+			if e.AStart != e.AEnd {
+				t.Errorf("inserted block %+v has a length in a", e)
+			}
+		}
+		if i > 0 && edits[i-1].Op == e.Op {
+			t.Errorf("edit %d %+v not merged with the one before", i, e)
+		}
+		ra = append(ra, a[e.AStart:e.AEnd]...)
+		rb = append(rb, b[e.BStart:e.BEnd]...)
+		aPos, bPos = e.AEnd, e.BEnd
+	}
+	if !bytes.Equal(ra, a) || !bytes.Equal(rb, b) {
+		t.Errorf("edits do not cover the inputs")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	texts := [][]byte{nil, []byte(testString), []byte(testImportance), []byte(testSimilarity), []byte(testCompact), testBinary}
+	for _, a := range texts {
+		for _, b := range texts {
+			edits := Diff(Parse(a).Compact(), Parse(b).Compact())
+			checkDiff(t, a, b, edits)
+			if bytes.Equal(a, b) && len(a) > 0 && (len(edits) != 1 || edits[0].Op != Shared) {
+				t.Errorf("identical inputs give %v", edits)
+			}
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		a := []byte(testString)
+		b := append([]byte(nil), a...)
+		for j := 0; j < 5; j++ {
+			p := r.Intn(len(b))
+			switch r.Intn(3) {
+			case 0:
+				b = append(b[:p], b[p+1:]...)
+			case 1:
+				b = append(b[:p], append([]byte("xyz"), b[p:]...)...)
+			default:
+				b[p] = 'Q'
+			}
+		}
+		edits := Diff(Parse(a).Compact(), Parse(b).Compact())
+		checkDiff(t, a, b, edits)
+		changed := 0
+		for _, e := range edits {
+			if e.Op != Shared {
+				changed += e.AEnd - e.AStart + e.BEnd - e.BStart
+			}
+		}
+		if changed > len(a)/4 {
+			t.Errorf("small changes gave %d changed bytes", changed)
+		}
+	}
+}
+
+func TestLCS(t *testing.T) {
+	for _, test := range []struct{ a, b string }{
+		{"abcabba", "cbabac"},
+		{"a", "a"},
+		{"a", "b"},
+		{"abc", "xaxbxcx"},
+		{"aaaa", "aa"},
+		{"abc", "xyz"},
+		{"", "abc"},
+		{"the quick brown fox", "a quick brown dog jumped"},
+	} {
+		pairs, ok := lcs(len(test.a), len(test.b), func(i, j int) bool { return test.a[i] == test.b[j] })
+		if !ok {
+			t.Fatalf("lcs(%q, %q) gave up", test.a, test.b)
+		}
+		for k, p := range pairs {
+			if test.a[p[0]] != test.b[p[1]] || k > 0 && (p[0] <= pairs[k-1][0] || p[1] <= pairs[k-1][1]) {
+				t.Errorf("bad pairs for %q %q: %v", test.a, test.b, pairs)
+			}
+		}
+		if want := lcsLength(test.a, test.b); len(pairs) != want {
+			t.Errorf("lcs(%q, %q) has %d pairs, want %d", test.a, test.b, len(pairs), want)
+		}
+	}
+}
+
+func TestLCSGivesUp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	n := 50000
+	a, b := make([]byte, n), make([]byte, n)
+	r.Read(a)
+	r.Read(b)
+	if _, ok := lcs(n, n, func(i, j int) bool { return a[i] == b[j] }); ok {
+		t.Error("lcs compared unrelated sequences far beyond its budget")
+	}
+}
+
+// TestDiffScaling checks that large repetitive inputs, and large unrelated ones, are compared quickly.
+func TestDiffScaling(t *testing.T) {
+	var a, b bytes.Buffer
+	for i := 0; a.Len() < 50000; i++ {
+		line := fmt.Sprintf("2023-05-01 12:%02d:%02d INFO worker %d finished job %d in %dms\n", i/60%60, i%60, i%7, i%11, 10+i%13)
+		a.WriteString(line)
+		if i%50 == 25 {
+			line = fmt.Sprintf("2023-05-01 12:%02d:%02d WARN worker %d retried job %d\n", i/60%60, i%60, i%7, i%11)
+		}
+		b.WriteString(line)
+	}
+	r := rand.New(rand.NewSource(1))
+	c, d := make([]byte, 20000), make([]byte, 20000)
+	for i := range c {
+		c[i], d[i] = 'a'+byte(r.Intn(26)), 'a'+byte(r.Intn(26))
+	}
+	for _, test := range []struct {
+		name string
+		a, b []byte
+	}{
+		{"repetitive", a.Bytes(), b.Bytes()},
+		{"unrelated", c, d},
+	} {
+		start := time.Now()
+		edits := Diff(Parse(test.a).Compact(), Parse(test.b).Compact())
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: took %v", test.name, elapsed)
+		}
+		checkDiff(t, test.a, test.b, edits)
+		if test.name == "repetitive" {
+			changed := 0
+			for _, e := range edits {
+				if e.Op != Shared {
+					changed += e.AEnd - e.AStart + e.BEnd - e.BStart
+				}
+			}
+			if changed > len(test.a)/10 {
+				t.Errorf("%s: %d bytes changed", test.name, changed)
+			}
+		}
+	}
+}
+
+func TestUniqueAnchors(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want string
+	}{
+		{"abcdef", "xbadcfe", "[[0 2] [2 4] [4 6]]"},
+		{"aabc", "abcc", "[[2 1]]"},
+		{"abab", "baba", "[]"},
+	} {
+		if got := fmt.Sprint(uniqueAnchors(terminals([]byte(test.a)), terminals([]byte(test.b)))); got != test.want {
+			t.Errorf("%q %q: got %s, want %s", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("parses megabytes")
+	}
+	var logs bytes.Buffer
+	for i := 0; logs.Len() < 1<<20; i++ {
+		fmt.Fprintf(&logs, "2023-05-01 %02d:%02d:%02d INFO worker %d finished job %d in %dms\n", i/3600%24, i/60%60, i%60, i%7, i*7919%10007, 10+i*31%997)
+	}
+	for _, a := range [][]byte{logs.Bytes(), []byte(randomish(1 << 20))} {
+		// insert about 4KB, delete 3KB and change 7 bytes, in three places
+		n := len(a)
+		var b []byte
+		b = append(b, a[:n/4]...)
+		b = append(b, bytes.Repeat([]byte("an inserted line\n"), 240)...)
+		b = append(b, a[n/4:n/2]...)
+		b = append(b, a[n/2+3000:3*n/4]...)
+		b = append(b, "CHANGED"...)
+		b = append(b, a[3*n/4+7:]...)
+
+		ca, cb := Parse(a).Compact(), Parse(b).Compact()
+		start := time.Now()
+		edits := Diff(ca, cb)
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%.20q: took %v", a, elapsed)
+		}
+		checkDiff(t, a, b, edits)
+		changed := 0
+		for _, e := range edits {
+			if e.Op != Shared {
+				changed += e.AEnd - e.AStart + e.BEnd - e.BStart
+			}
+		}
+		if changed > 2*(240*17+3000+14) {
+			t.Errorf("%.20q: %d bytes changed", a, changed)
+		}
+	}
+}
+
+func lcsLength(a, b string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] > dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
@@ -231,6 +231,18 @@ func (comp *Compact) PrettyPrint(w io.Writer) error {
 	return nil
 }
 
+// Size of a Compact grammar, as the total number of symbols on the right-hand sides of its rules.
+func (comp *Compact) Size() int {
+	if comp == nil {
+		return 0
+	}
+	size := 0
+	for _, v := range comp.Map {
+		size += len(v.IDs)
+	}
+	return size
+}
+
 // Bytes of a Compact grammar SymbolID, including all of the symbols that it contains.
 func (comp *Compact) Bytes(sid SymbolID) []byte {
 	if sid == EmptySymbolID || comp == nil {
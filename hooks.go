@@ -0,0 +1,48 @@
+package sequitur
+
+// Hooks are called at each step of the algorithm as a grammar is built by ParseContext or Grammar.Append, to trace or measure it.
+// Any of them may be nil. Each is given the grammar being built, which must not be modified,
+// and which may be part of the way through a step.
+type Hooks struct {
+	// RuleCreated is called when a digram has been seen twice, so both occurrences have been replaced by a new rule.
+	RuleCreated func(g *Grammar, rule SymbolID, digram [2]SymbolID)
+
+	// DigramReplaced is called when a digram has been replaced by the existing rule consisting of just that digram.
+	DigramReplaced func(g *Grammar, rule SymbolID)
+
+	// RuleInlined is called when a rule used only once has been replaced by its contents, enforcing rule utility.
+	RuleInlined func(g *Grammar, rule SymbolID)
+
+	// SymbolAppended is called when a terminal from the input has been added, and the grammar has been updated for it.
+	SymbolAppended func(g *Grammar, sym SymbolID)
+}
+
+// SetHooks sets the hooks called as input is added to the grammar by Append, replacing any set before, or removes them if h is nil.
+func (g *Grammar) SetHooks(h *Hooks) {
+	g.hooks = h
+}
+
+func (g *Grammar) ruleCreated(r *rules) {
+	if g.hooks != nil && g.hooks.RuleCreated != nil {
+		f := r.first()
+		g.hooks.RuleCreated(g, SymbolID(r.id), [2]SymbolID{SymbolID(f.value), SymbolID(f.next.value)})
+	}
+}
+
+func (g *Grammar) digramReplaced(r *rules) {
+	if g.hooks != nil && g.hooks.DigramReplaced != nil {
+		g.hooks.DigramReplaced(g, SymbolID(r.id))
+	}
+}
+
+func (g *Grammar) ruleInlined(r *rules) {
+	if g.hooks != nil && g.hooks.RuleInlined != nil {
+		g.hooks.RuleInlined(g, SymbolID(r.id))
+	}
+}
+
+func (g *Grammar) symbolAppended(v uint64) {
+	if g.hooks != nil && g.hooks.SymbolAppended != nil {
+		g.hooks.SymbolAppended(g, SymbolID(v))
+	}
+}
@@ -0,0 +1,112 @@
+package sequitur
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleHooks() {
+
+	// the examples from https://en.wikipedia.org/wiki/Sequitur_algorithm
+	for _, input := range []string{"abcab", "abaaba"} {
+		fmt.Println(input)
+		hooks := &Hooks{
+			RuleCreated: func(g *Grammar, rule SymbolID, digram [2]SymbolID) {
+				fmt.Println("  created", rule, "->", digram[0], digram[1])
+			},
+			DigramReplaced: func(g *Grammar, rule SymbolID) {
+				fmt.Println("  replaced by", rule)
+			},
+			RuleInlined: func(g *Grammar, rule SymbolID) {
+				fmt.Println("  inlined", rule)
+			},
+			SymbolAppended: func(g *Grammar, sym SymbolID) {
+				var b bytes.Buffer
+				g.PrettyPrint(&b)
+				fmt.Printf("%s: %s\n", sym, strings.Replace(strings.TrimSpace(b.String()), "\n", ", ", -1))
+			},
+		}
+		if _, err := ParseContext(context.Background(), strings.NewReader(input), ParseOptions{Hooks: hooks}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	// Output:
+	// abcab
+	// a: 0 -> a
+	// b: 0 -> a b
+	// c: 0 -> a b c
+	// a: 0 -> a b c a
+	//   created 1114370 -> a b
+	// b: 0 -> 1 c 1, 1 -> a b
+	// abaaba
+	// a: 0 -> a
+	// b: 0 -> a b
+	// a: 0 -> a b a
+	// a: 0 -> a b a a
+	//   created 1114370 -> a b
+	// b: 0 -> 1 a 1, 1 -> a b
+	//   created 1114371 -> 1114370 a
+	//   inlined 1114370
+	// a: 0 -> 1 1, 1 -> a b a
+}
+
+func TestHooksDigramReplaced(t *testing.T) {
+	var replaced []SymbolID
+	appended := 0
+	hooks := &Hooks{
+		DigramReplaced: func(g *Grammar, rule SymbolID) { replaced = append(replaced, rule) },
+		SymbolAppended: func(g *Grammar, sym SymbolID) { appended++ },
+	}
+	if _, err := ParseContext(context.Background(), strings.NewReader("ababab"), ParseOptions{Hooks: hooks}); err != nil {
+		t.Fatal(err)
+	}
+	if appended != 6 {
+		t.Errorf("SymbolAppended called %d times, want 6", appended)
+	}
+	if len(replaced) != 1 || replaced[0] != SymbolID(firstRuleID+1) {
+		t.Errorf("DigramReplaced called with %v, want [%d]", replaced, firstRuleID+1)
+	}
+}
+
+func TestSetHooks(t *testing.T) {
+	var want, got []string
+	record := func(events *[]string) *Hooks {
+		return &Hooks{
+			RuleCreated: func(g *Grammar, rule SymbolID, digram [2]SymbolID) {
+				*events = append(*events, fmt.Sprint("created ", rule))
+			},
+			DigramReplaced: func(g *Grammar, rule SymbolID) { *events = append(*events, fmt.Sprint("replaced ", rule)) },
+			RuleInlined:    func(g *Grammar, rule SymbolID) { *events = append(*events, fmt.Sprint("inlined ", rule)) },
+			SymbolAppended: func(g *Grammar, sym SymbolID) { *events = append(*events, fmt.Sprint("appended ", sym)) },
+		}
+	}
+	if _, err := ParseContext(context.Background(), strings.NewReader(testString), ParseOptions{Hooks: record(&want)}); err != nil {
+		t.Fatal(err)
+	}
+
+	g := Parse(nil)
+	g.SetHooks(record(&got))
+	g.Append([]byte(testString[:100]))
+	g.Append([]byte(testString[100:]))
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %d steps, want the %d of ParseContext", len(got), len(want))
+	}
+	if !Equal(g.Compact(), Parse([]byte(testString)).Compact()) {
+		t.Error("appending gave a different grammar to Parse")
+	}
+
+	got = nil
+	g.SetHooks(nil)
+	g.Append([]byte("more"))
+	var zero Grammar
+	zero.SetHooks(record(&got))
+	zero.Append([]byte("abab"))
+	if len(got) != 5 || !Equal(zero.Compact(), Parse([]byte("abab")).Compact()) {
+		t.Errorf("zero grammar gave steps %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package sequitur
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+)
+
+// HTMLOptions control the page written by WriteHTML.
+type HTMLOptions struct {
+	Title    string // the title of the page
+	MaxDepth int    // the deepest rules to mark, counting the rules used by the root as depth 1, or all of them if 0
+	Naming   Naming // how rules are named, as by Printer
+
+	// Terminal, if not nil, gives the text of each terminal, as for grammars of Tokens.
+	Terminal func(sid SymbolID) string
+}
+
+// WriteHTML writes a page with no external dependencies showing the input of comp,
+// with each occurrence of a rule marked by a span colored by rule and nested as the rules are.
+// Hovering over an occurrence highlights every occurrence of the same rule and shows its name and usage.
+func WriteHTML(w io.Writer, comp *Compact, opts HTMLOptions) error {
+	var b strings.Builder
+	if comp != nil && comp.RootID != EmptySymbolID {
+		rules, number := comp.Appearance()
+		p := Printer{Naming: opts.Naming}
+		names := make(map[SymbolID]string, len(rules))
+		for _, id := range rules {
+			names[id] = p.name(id, number[id])
+		}
+
+		occurrences := comp.occurrenceCounts()
+
+		var render func(id SymbolID, depth int)
+		render = func(id SymbolID, depth int) {
+			for _, sid := range comp.Map[id].IDs {
+				if !sid.IsRule() {
+					text := string(runeOrByte(sid).appendBytes(nil))
+					if opts.Terminal != nil {
+						text = opts.Terminal(sid)
+					}
+					b.WriteString(template.HTMLEscapeString(text))
+					continue
+				}
+				if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+					render(sid, depth+1)
+					continue
+				}
+				// spread the hues of the rules in order of appearance around the color wheel
+				hue := math.Mod(float64(number[sid])*137.508, 360)
+				fmt.Fprintf(&b, `<span class="r" data-rule="%s" style="--h:%.0f" title="rule %s: used %d times, %d occurrences">`,
+					template.HTMLEscapeString(names[sid]), hue, template.HTMLEscapeString(names[sid]), comp.Map[sid].Used, occurrences[sid])
+				render(sid, depth+1)
+				b.WriteString("</span>")
+			}
+		}
+		render(comp.RootID, 0)
+	}
+	return htmlPage.Execute(w, struct {
+		Title string
+		Text  template.HTML
+	}{opts.Title, template.HTML(b.String())})
+}
+
+var htmlPage = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+#text { font-family: monospace; white-space: pre-wrap; line-height: 1.8; }
+.r { background: hsla(var(--h), 80%, 60%, 0.15); border-bottom: 2px solid hsl(var(--h), 70%, 45%); padding-bottom: 1px; }
+.r.on { background: hsla(var(--h), 90%, 55%, 0.5); outline: 1px solid hsl(var(--h), 70%, 35%); }
+#info { position: sticky; top: 0; background: #fff; color: #555; min-height: 1.5em; padding: 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div id="info"></div>
+<div id="text">{{.Text}}</div>
+<script>
+var text = document.getElementById("text"), info = document.getElementById("info"), on = [];
+text.addEventListener("mouseover", function(e) {
+	on.forEach(function(s) { s.classList.remove("on"); });
+	var span = e.target.closest(".r");
+	on = span ? Array.prototype.slice.call(text.querySelectorAll('.r[data-rule="' + span.dataset.rule + '"]')) : [];
+	on.forEach(function(s) { s.classList.add("on"); });
+	info.textContent = span ? span.title : "";
+});
+</script>
+</body>
+</html>
+`))
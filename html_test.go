@@ -0,0 +1,57 @@
+package sequitur
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// annotated gives the text written by WriteHTML, with the spans reduced to the names of their rules.
+func annotated(t *testing.T, comp *Compact, opts HTMLOptions) string {
+	var b bytes.Buffer
+	if err := WriteHTML(&b, comp, opts); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	start := strings.Index(page, `<div id="text">`) + len(`<div id="text">`)
+	end := strings.Index(page, "</div>\n<script>")
+	return regexp.MustCompile(`<span class="r" data-rule="([^"]*)"[^>]*>`).ReplaceAllString(page[start:end], "[$1 ")
+}
+
+func TestWriteHTML(t *testing.T) {
+	comp := Parse([]byte("abcdbcabcd<&>")).Compact()
+	for _, tc := range []struct {
+		opts HTMLOptions
+		want string
+	}{
+		{HTMLOptions{}, "[1 a[2 bc</span>d</span>[2 bc</span>[1 a[2 bc</span>d</span>&lt;&amp;&gt;"},
+		{HTMLOptions{MaxDepth: 1, Naming: NameLetters}, "[A abcd</span>[B bc</span>[A abcd</span>&lt;&amp;&gt;"},
+	} {
+		if got := annotated(t, comp, tc.opts); got != tc.want {
+			t.Errorf("%+v: got %s, want %s", tc.opts, got, tc.want)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := WriteHTML(&b, comp, HTMLOptions{Title: "<title>"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`title="rule 2: used 2 times, 3 occurrences"`, "<h1>&lt;title&gt;</h1>"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("page does not contain %s", want)
+		}
+	}
+}
+
+func TestWriteHTMLText(t *testing.T) {
+	tags := regexp.MustCompile(`<[^>]*>`)
+	for _, input := range []string{"", testCompact, testImportance} {
+		got := html.UnescapeString(tags.ReplaceAllString(annotated(t, Parse([]byte(input)).Compact(), HTMLOptions{}), ""))
+		got = regexp.MustCompile(`\[\d+ `).ReplaceAllString(got, "")
+		if got != input {
+			t.Errorf("text %q, want %q", got, input)
+		}
+	}
+}
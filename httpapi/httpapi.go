@@ -0,0 +1,430 @@
+// Package httpapi serves sequitur grammar inference over HTTP, so that programs in other languages can use it.
+//
+// Every endpoint takes a POST request and, apart from /expand, responds with JSON:
+//
+//	/parse       the grammar of the request body
+//	/expand      the input of a grammar in the request body, as given by /parse
+//	/stats       the size and shape of the grammar of the request body
+//	/importance  the rule strings of the request body ranked by importance
+//	/similarity  the similarity of each pair of the files of a multipart/form-data request
+//
+// Request bodies are parsed as they arrive rather than read into memory first, and parsing stops
+// if the client goes away. Errors are reported as {"error": "..."} with a 4xx or 5xx status.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// DefaultMaxBytes is used when Options.MaxBytes is zero.
+const DefaultMaxBytes = 16 << 20
+
+// DefaultMaxExpansion is used when Options.MaxExpansion is zero.
+const DefaultMaxExpansion = 256 << 20
+
+// DefaultMaxDocuments is used when Options.MaxDocuments is zero.
+const DefaultMaxDocuments = 100
+
+// DefaultMaxCompression is used when Options.MaxCompression is zero.
+const DefaultMaxCompression = 256 << 20
+
+// Options for New.
+type Options struct {
+	MaxBytes     int64 // the longest request body accepted
+	MaxExpansion int64 // the longest input /expand will give, as a small grammar can have a very long expansion
+	MaxDocuments int   // the most files accepted by /similarity
+
+	// MaxCompression is the most input /similarity will parse for the compression measure, which parses
+	// the concatenation of every pair of files, so that the cost grows with the square of the number of files.
+	MaxCompression int64
+}
+
+// Grammar is the JSON form of a sequitur.Compact, as written by /parse and read by /expand.
+// It is the same as the form saved by the compact command of cmd/sequitur for rune tokenization.
+type Grammar struct {
+	Root  sequitur.SymbolID                           `json:"root"`
+	Rules map[sequitur.SymbolID]sequitur.CompactEntry `json:"rules"`
+}
+
+// Stats describes the size and shape of a grammar.
+type Stats struct {
+	Bytes      int     `json:"bytes"`       // the length of the input
+	Symbols    int     `json:"symbols"`     // the number of runes (or invalid bytes) in the input
+	Rules      int     `json:"rules"`       // not counting the top-level rule
+	RootLength int     `json:"root_length"` // the number of symbols in the top-level rule
+	Size       int     `json:"size"`        // the number of symbols in all of the rules
+	Ratio      float64 `json:"ratio"`       // Size divided by Symbols
+	Depth      int     `json:"depth"`       // the deepest nesting of rules, 1 if the top-level rule uses no others
+}
+
+// RuleString is a rule string ranked by /importance.
+type RuleString = sequitur.RuleString
+
+// Similarities is the response of /similarity.
+type Similarities struct {
+	Documents []string    `json:"documents"` // the file names of the parts, in the order they were sent
+	Measure   string      `json:"measure"`
+	Matrix    [][]float64 `json:"matrix"` // Matrix[i][j] is the similarity of document i to document j
+}
+
+type handler struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// New gives a handler serving the endpoints described in the package documentation.
+func New(opts Options) http.Handler {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.MaxExpansion <= 0 {
+		opts.MaxExpansion = DefaultMaxExpansion
+	}
+	if opts.MaxDocuments <= 0 {
+		opts.MaxDocuments = DefaultMaxDocuments
+	}
+	if opts.MaxCompression <= 0 {
+		opts.MaxCompression = DefaultMaxCompression
+	}
+	h := &handler{opts: opts, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/parse", h.post(h.parse))
+	h.mux.HandleFunc("/expand", h.post(h.expand))
+	h.mux.HandleFunc("/stats", h.post(h.stats))
+	h.mux.HandleFunc("/importance", h.post(h.importance))
+	h.mux.HandleFunc("/similarity", h.post(h.similarity))
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// errTooLarge is returned when reading more than Options.MaxBytes of a request body.
+var errTooLarge = errors.New("request body too large")
+
+// httpError is an error with the status it should be reported with.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+// limitedReader reads from r, failing with errTooLarge once more than n bytes have been read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errTooLarge
+	}
+	return n, err
+}
+
+// post adapts fn to a handler accepting only POST requests, with a limited body, and reporting its errors as JSON.
+func (h *handler) post(fn func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, &httpError{http.StatusMethodNotAllowed, errors.New("method not allowed")})
+			return
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{&limitedReader{r.Body, h.opts.MaxBytes}, r.Body}
+		if err := fn(w, r); err != nil {
+			writeError(w, err)
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		status = he.status
+	case errors.Is(err, errTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+	}
+	if status == http.StatusRequestEntityTooLarge {
+		w.Header().Set("Connection", "close")
+	}
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) // the client has gone if this fails
+}
+
+// parseBody parses a request body, or part of one, as it is read.
+func parseBody(ctx context.Context, r io.Reader) (*sequitur.Compact, error) {
+	g, err := sequitur.ParseContext(ctx, r, sequitur.ParseOptions{})
+	switch {
+	case err == nil:
+	case errors.Is(err, errTooLarge), ctx.Err() != nil:
+		return nil, err
+	default:
+		return nil, badRequest("reading request: %v", err)
+	}
+	return g.Compact(), nil
+}
+
+func (h *handler) parse(w http.ResponseWriter, r *http.Request) error {
+	comp, err := parseBody(r.Context(), r.Body)
+	if err != nil {
+		return err
+	}
+	comp = comp.Canonical()
+	writeJSON(w, http.StatusOK, Grammar{Root: comp.RootID, Rules: comp.Map})
+	return nil
+}
+
+func (h *handler) expand(w http.ResponseWriter, r *http.Request) error {
+	var g Grammar
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		if errors.Is(err, errTooLarge) {
+			return err
+		}
+		return badRequest("reading grammar: %v", err)
+	}
+	comp, err := g.Compact()
+	if err != nil {
+		return badRequest("%v", err)
+	}
+	if n := expansionLength(comp, h.opts.MaxExpansion); n > h.opts.MaxExpansion {
+		return &httpError{http.StatusRequestEntityTooLarge, fmt.Errorf("grammar expands to more than %d bytes", h.opts.MaxExpansion)}
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, err = w.Write(comp.Bytes(comp.RootID))
+	return err
+}
+
+// Compact gives the grammar as a sequitur.Compact, after checking that it can be expanded with sequitur.Compact.Check.
+func (g *Grammar) Compact() (*sequitur.Compact, error) {
+	comp := &sequitur.Compact{RootID: g.Root, Map: g.Rules}
+	if err := comp.Check(nil); err != nil {
+		return nil, err
+	}
+	return comp, nil
+}
+
+// expansionLength gives the length in bytes of the input of comp, or limit+1 if it is longer than limit.
+func expansionLength(comp *sequitur.Compact, limit int64) int64 {
+	lengths := make(map[sequitur.SymbolID]int64)
+	var length func(id sequitur.SymbolID) int64
+	length = func(id sequitur.SymbolID) int64 {
+		if !id.IsRule() {
+			return int64(len(id.Bytes(comp)))
+		}
+		if l, ok := lengths[id]; ok {
+			return l
+		}
+		var l int64
+		for _, sid := range comp.Map[id].IDs {
+			if l += length(sid); l > limit {
+				l = limit + 1
+				break
+			}
+		}
+		lengths[id] = l
+		return l
+	}
+	if comp.RootID == sequitur.EmptySymbolID {
+		return 0
+	}
+	return length(comp.RootID)
+}
+
+func (h *handler) stats(w http.ResponseWriter, r *http.Request) error {
+	comp, err := parseBody(r.Context(), r.Body)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, statsOf(comp))
+	return nil
+}
+
+func statsOf(comp *sequitur.Compact) Stats {
+	shape := comp.Shape()
+	s := Stats{
+		Symbols:    shape.Symbols,
+		Rules:      shape.Rules,
+		RootLength: shape.RootLength,
+		Size:       shape.Size,
+		Ratio:      shape.Ratio,
+		Depth:      shape.Depth,
+	}
+	if comp.RootID != sequitur.EmptySymbolID {
+		s.Bytes = len(comp.Bytes(comp.RootID))
+	}
+	return s
+}
+
+// intParam gives the integer query parameter name, or def if there is none.
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, badRequest("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+// importance ranks the rule strings of the body. The query parameters are
+// n, the number of strings (default 10, 0 for all), min and max, the shortest and longest strings in bytes
+// after trimming white space (default 1, and 0 for no limit), and score, either coverage or used (coverage×used², the default).
+func (h *handler) importance(w http.ResponseWriter, r *http.Request) error {
+	n, err := intParam(r, "n", 10)
+	if err != nil {
+		return err
+	}
+	minLength, err := intParam(r, "min", 1)
+	if err != nil {
+		return err
+	}
+	maxLength, err := intParam(r, "max", 0)
+	if err != nil {
+		return err
+	}
+	scoring := r.URL.Query().Get("score")
+	if scoring == "" {
+		scoring = "used"
+	}
+	if scoring != "used" && scoring != "coverage" {
+		return badRequest("unknown score %q: want coverage or used", scoring)
+	}
+
+	comp, err := parseBody(r.Context(), r.Body)
+	if err != nil {
+		return err
+	}
+	ci := comp.Index(sequitur.KeepLength(minLength, maxLength, true))
+	var scoreFn func(sid sequitur.SymbolID) float64
+	if scoring == "used" {
+		scoreFn = ci.UsedScore
+	}
+	ranked := ci.RuleStrings(ci.Importance(scoreFn), n, true)
+	writeJSON(w, http.StatusOK, ranked)
+	return nil
+}
+
+var measures = map[string]sequitur.SimilarityMeasure{}
+
+func init() {
+	for _, m := range []sequitur.SimilarityMeasure{sequitur.CoverageOverlap, sequitur.Jaccard, sequitur.UsageCosine, sequitur.Containment, sequitur.Compression} {
+		measures[m.String()] = m
+	}
+}
+
+// similarity compares the files of a multipart/form-data body, using the measure named by the query parameter measure
+// (coverage, the default, jaccard, cosine, containment or compression).
+func (h *handler) similarity(w http.ResponseWriter, r *http.Request) error {
+	name := r.URL.Query().Get("measure")
+	if name == "" {
+		name = sequitur.CoverageOverlap.String()
+	}
+	measure, ok := measures[name]
+	if !ok {
+		return badRequest("unknown measure %q", name)
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "multipart/form-data" {
+		return badRequest("want a multipart/form-data request")
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return badRequest("%v", err)
+	}
+
+	resp := Similarities{Documents: []string{}, Measure: name}
+	var docs []*sequitur.CompactIndexed
+	var total int64 // the length of all of the inputs
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, errTooLarge) {
+				return err
+			}
+			return badRequest("%v", err)
+		}
+		if part.FileName() == "" {
+			continue // not a file
+		}
+		if len(docs) == h.opts.MaxDocuments {
+			return badRequest("more than %d documents", h.opts.MaxDocuments)
+		}
+		comp, err := parseBody(r.Context(), part)
+		if err != nil {
+			return err
+		}
+		resp.Documents = append(resp.Documents, part.FileName())
+		docs = append(docs, comp.Index(nil))
+		total += expansionLength(comp, h.opts.MaxBytes)
+	}
+	// every document is concatenated with every one, itself included, once on each side,
+	// and the smaller of each pair with itself, which is no longer
+	if measure == sequitur.Compression && 4*int64(len(docs))*total > h.opts.MaxCompression {
+		return &httpError{http.StatusRequestEntityTooLarge, fmt.Errorf("comparing %d documents of %d bytes by compression would parse more than %d bytes",
+			len(docs), total, h.opts.MaxCompression)}
+	}
+
+	resp.Matrix = make([][]float64, len(docs))
+	for i := range docs {
+		resp.Matrix[i] = make([]float64, len(docs))
+	}
+	if measure == sequitur.CoverageOverlap {
+		m := sequitur.SimilarityMatrix(docs, 0)
+		for i := range docs {
+			for j := range docs {
+				resp.Matrix[i][j] = m.At(i, j)
+			}
+		}
+	} else {
+		for i := range docs {
+			if err := r.Context().Err(); err != nil {
+				return err
+			}
+			for j := range docs {
+				resp.Matrix[i][j] = docs[i].SimilarityBy(docs[j], measure)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+	return nil
+}
@@ -0,0 +1,196 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, h http.Handler, path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseExpand(t *testing.T) {
+	h := New(Options{})
+	for _, input := range []string{"abcdbcabcd", "", "invalid \xff\xfe\xff\xfe utf-8"} {
+		rec := post(t, h, "/parse", "", strings.NewReader(input))
+		saved := rec.Body.String()
+		var g Grammar
+		decode(t, rec, &g)
+		if input == "abcdbcabcd" && (len(g.Rules) != 3 || g.Root != 1114369) {
+			t.Errorf("%q: got grammar %+v", input, g)
+		}
+		rec = post(t, h, "/expand", "application/json", strings.NewReader(saved))
+		if rec.Code != http.StatusOK || rec.Body.String() != input {
+			t.Errorf("%q: expand gave %d %q", input, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	var got Stats
+	decode(t, post(t, New(Options{}), "/stats", "", strings.NewReader("abcdbcabcd")), &got)
+	want := Stats{Bytes: 10, Symbols: 10, Rules: 2, RootLength: 3, Size: 8, Ratio: 0.8, Depth: 3}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestImportance(t *testing.T) {
+	text := strings.Repeat("the quick brown fox ", 4) + strings.Repeat("jumps over ", 2)
+	var got []RuleString
+	decode(t, post(t, New(Options{}), "/importance?n=2&min=5&score=coverage", "", strings.NewReader(text)), &got)
+	if len(got) != 2 || !strings.Contains(got[0].String, "the quick brown fox") || len(got[1].String) < 5 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, doc := range []struct{ name, text string }{
+		{"a.txt", strings.Repeat("the quick brown fox jumps over the lazy dog. ", 5)},
+		{"b.txt", strings.Repeat("the quick brown fox jumps over the lazy dog. ", 4)},
+		{"c.txt", strings.Repeat("lorem ipsum dolor sit amet. ", 5)},
+	} {
+		fw, err := mw.CreateFormFile("doc", doc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, doc.text)
+	}
+	mw.WriteField("note", "not a document")
+	mw.Close()
+
+	for _, measure := range []string{"", "jaccard", "cosine", "containment", "compression"} {
+		var got Similarities
+		decode(t, post(t, New(Options{}), "/similarity?measure="+measure, mw.FormDataContentType(), bytes.NewReader(body.Bytes())), &got)
+		if strings.Join(got.Documents, " ") != "a.txt b.txt c.txt" || len(got.Matrix) != 3 {
+			t.Fatalf("%s: got %+v", measure, got)
+		}
+		if m := got.Matrix; m[0][1] <= m[0][2] || m[1][0] <= m[1][2] {
+			t.Errorf("%s: a and b not the most similar: %v", measure, m)
+		}
+	}
+
+	if rec := post(t, New(Options{MaxDocuments: 2}), "/similarity", mw.FormDataContentType(), bytes.NewReader(body.Bytes())); rec.Code != http.StatusBadRequest {
+		t.Errorf("too many documents: got status %d", rec.Code)
+	}
+
+	// the three documents are 545 bytes long, so comparing them by compression parses at most 12×545 bytes
+	for _, tc := range []struct {
+		measure string
+		limit   int64
+		want    int
+	}{
+		{"compression", 6539, http.StatusRequestEntityTooLarge},
+		{"compression", 6540, http.StatusOK},
+		{"coverage", 1, http.StatusOK},
+	} {
+		rec := post(t, New(Options{MaxCompression: tc.limit}), "/similarity?measure="+tc.measure, mw.FormDataContentType(), bytes.NewReader(body.Bytes()))
+		if rec.Code != tc.want {
+			t.Errorf("%s with limit %d: got status %d, want %d", tc.measure, tc.limit, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := New(Options{MaxBytes: 100})
+	for _, tc := range []struct {
+		method, path, contentType, body string
+		want                            int
+	}{
+		{http.MethodGet, "/parse", "", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/parse", "", strings.Repeat("x", 101), http.StatusRequestEntityTooLarge},
+		{http.MethodPost, "/parse", "", strings.Repeat("x", 100), http.StatusOK},
+		{http.MethodPost, "/expand", "", "{", http.StatusBadRequest},
+		{http.MethodPost, "/expand", "", `{"root":1114369,"rules":{"1114369":{"IDs":[1114370]}}}`, http.StatusBadRequest},
+		{http.MethodPost, "/expand", "", `{"root":1114369,"rules":{"1114369":{"IDs":[1114369]}}}`, http.StatusBadRequest},
+		{http.MethodPost, "/importance?n=x", "", "abc", http.StatusBadRequest},
+		{http.MethodPost, "/importance?score=tfidf", "", "abc", http.StatusBadRequest},
+		{http.MethodPost, "/similarity", "text/plain", "abc", http.StatusBadRequest},
+		{http.MethodPost, "/similarity?measure=euclid", "", "", http.StatusBadRequest},
+		{http.MethodPost, "/nonesuch", "", "", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s: got status %d, want %d: %s", tc.method, tc.path, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
+
+func TestExpansionLimit(t *testing.T) {
+	// each rule doubles the one below it, so that the root expands to 256 runes
+	var rules []string
+	for id := 1114369; id < 1114376; id++ {
+		rules = append(rules, fmt.Sprintf(`"%d":{"IDs":[%d,%d]}`, id, id+1, id+1))
+	}
+	rules = append(rules, `"1114376":{"IDs":[353,353]}`)
+	bomb := `{"root":1114369,"rules":{` + strings.Join(rules, ",") + `}}`
+	for _, tc := range []struct {
+		limit int64
+		want  int
+	}{
+		{255, http.StatusRequestEntityTooLarge},
+		{256, http.StatusOK},
+	} {
+		rec := post(t, New(Options{MaxExpansion: tc.limit}), "/expand", "", strings.NewReader(bomb))
+		if rec.Code != tc.want {
+			t.Errorf("limit %d: got status %d, want %d", tc.limit, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	srv := httptest.NewServer(New(Options{}))
+	defer srv.Close()
+
+	// stream the body, so that it is parsed as it arrives
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 100; i++ {
+			io.WriteString(pw, "streamed in pieces ")
+		}
+		pw.Close()
+	}()
+	resp, err := http.Post(srv.URL+"/stats", "text/plain", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got Stats
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || got.Bytes != 1900 {
+		t.Errorf("got status %d and %+v", resp.StatusCode, got)
+	}
+}
@@ -0,0 +1,174 @@
+package sequitur
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Keyword is a phrase of whole words found by the rules of a grammar.
+type Keyword struct {
+	Phrase    string
+	Score     float64 // the proportion of the original input covered by the phrase
+	Positions []int   // the byte offset in the original input of each occurrence, in ascending order
+}
+
+// KeywordOptions for Keywords.
+type KeywordOptions struct {
+	StopWords map[string]bool // lower case words which cannot make up a phrase on their own, DefaultStopWords if nil
+	Max       int             // the most keywords reported, or all of them if zero
+}
+
+// DefaultStopWords are common English words.
+var DefaultStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "been": true,
+	"but": true, "by": true, "can": true, "for": true, "from": true, "has": true, "have": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "so": true, "such": true, "that": true, "the": true, "their": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true, "was": true, "were": true,
+	"which": true, "will": true, "with": true,
+}
+
+// isWordRune says if r is part of a word: letters, marks and digits are, everything else separates words.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+}
+
+// snapWords shrinks [start, end) of input to the whole words within it, giving start >= end if there are none.
+func snapWords(input []byte, start, end int) (int, int) {
+	wordAt := func(i int) bool {
+		r, _ := utf8.DecodeRune(input[i:])
+		return isWordRune(r)
+	}
+	wordBefore := func(i int) bool {
+		r, _ := utf8.DecodeLastRune(input[:i])
+		return isWordRune(r)
+	}
+	if start > 0 && start < end && wordBefore(start) {
+		// starts in the middle of a word
+		for start < end && wordAt(start) {
+			_, sz := utf8.DecodeRune(input[start:])
+			start += sz
+		}
+	}
+	for start < end && !wordAt(start) {
+		_, sz := utf8.DecodeRune(input[start:])
+		start += sz
+	}
+	if end < len(input) && start < end && wordAt(end) {
+		// ends in the middle of a word
+		for start < end && wordBefore(end) {
+			_, sz := utf8.DecodeLastRune(input[:end])
+			end -= sz
+		}
+	}
+	for start < end && !wordBefore(end) {
+		_, sz := utf8.DecodeLastRune(input[:end])
+		end -= sz
+	}
+	return start, end
+}
+
+// Keywords extracts phrases from the indexed rules. Each occurrence of a rule is shrunk to the whole words within it,
+// phrases consisting only of stop words are dropped, and occurrences overlapping those of a higher scoring phrase
+// are removed, leaving only phrases which still occur more than once.
+func (ci *CompactIndexed) Keywords(opts KeywordOptions) []Keyword {
+	if ci == nil || ci.CompactBasis == nil || ci.OriginalInputLength == 0 {
+		return nil
+	}
+	stop := opts.StopWords
+	if stop == nil {
+		stop = DefaultStopWords
+	}
+	comp := ci.CompactBasis
+	input := comp.Bytes(comp.RootID)
+
+	positions := make(map[string]map[int]bool)
+	for _, o := range comp.Occurrences() {
+		if _, indexed := ci.IDinfo[o.ID]; !indexed {
+			continue
+		}
+		start, end := snapWords(input, o.Start, o.End)
+		if start >= end {
+			continue
+		}
+		phrase := string(input[start:end])
+		if positions[phrase] == nil {
+			positions[phrase] = make(map[int]bool)
+		}
+		positions[phrase][start] = true
+	}
+
+	var candidates []Keyword
+	for phrase, pos := range positions {
+		if len(pos) < 2 || onlyStopWords(phrase, stop) {
+			continue
+		}
+		k := Keyword{Phrase: phrase}
+		for p := range pos {
+			k.Positions = append(k.Positions, p)
+		}
+		sort.Ints(k.Positions)
+		k.Score = ci.phraseScore(k)
+		candidates = append(candidates, k)
+	}
+	sortKeywords(candidates)
+
+	taken := make([]bool, len(input))
+	keywords := candidates[:0]
+	for _, k := range candidates {
+		free := k.Positions[:0]
+		for _, p := range k.Positions {
+			overlaps := false
+			for i := p; i < p+len(k.Phrase) && !overlaps; i++ {
+				overlaps = taken[i]
+			}
+			if !overlaps {
+				free = append(free, p)
+			}
+		}
+		if len(free) < 2 {
+			continue
+		}
+		for _, p := range free {
+			for i := p; i < p+len(k.Phrase); i++ {
+				taken[i] = true
+			}
+		}
+		k.Positions = free
+		k.Score = ci.phraseScore(k)
+		keywords = append(keywords, k)
+	}
+	sortKeywords(keywords)
+	if opts.Max > 0 && len(keywords) > opts.Max {
+		keywords = keywords[:opts.Max]
+	}
+	return keywords
+}
+
+func (ci *CompactIndexed) phraseScore(k Keyword) float64 {
+	return float64(len(k.Positions)*len(k.Phrase)) / float64(ci.OriginalInputLength)
+}
+
+func sortKeywords(keywords []Keyword) {
+	sort.Slice(keywords, func(i, j int) bool {
+		a, b := keywords[i], keywords[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Phrase) != len(b.Phrase) {
+			return len(a.Phrase) > len(b.Phrase)
+		}
+		return a.Phrase < b.Phrase
+	})
+}
+
+func onlyStopWords(phrase string, stop map[string]bool) bool {
+	for _, word := range strings.FieldsFunc(phrase, func(r rune) bool { return !isWordRune(r) }) {
+		if !stop[strings.ToLower(word)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package sequitur
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func ExampleCompactIndexed_Keywords() {
+
+	idx := Parse([]byte(testImportance)).Compact().Index(nil)
+
+	for k, v := range idx.Keywords(KeywordOptions{Max: 10}) {
+		fmt.Printf("%d %7.5f %q %d\n", k, v.Score, v.Phrase, len(v.Positions))
+	}
+
+	// Output:
+	// 0 0.02496 "grammar" 14
+	// 1 0.02063 "algorithm" 9
+	// 2 0.02037 "Nevill-Manning, C.G.; Witten, I.H. (1997" 2
+	// 3 0.01833 "symbol" 12
+	// 4 0.01222 "Sequitur" 6
+	// 5 0.01019 "uniqueness" 4
+	// 6 0.00968 "For example, in the" 2
+	// 7 0.00840 "definitions" 3
+	// 8 0.00815 "sequence" 4
+	// 9 0.00764 "This constraint" 2
+}
+
+func TestSnapWords(t *testing.T) {
+	input := []byte("the sequence, of équipe")
+	for _, test := range []struct {
+		start, end int
+		want       string
+	}{
+		{0, 3, "the"},
+		{0, 4, "the"},
+		{1, 12, "sequence"}, // ends at a word boundary, but starts mid-word
+		{5, 12, ""},
+		{3, 16, "sequence, of"},
+		{0, len(input), "the sequence, of équipe"},
+		{16, len(input) - 1, ""},
+		{12, 15, ""},
+	} {
+		start, end := snapWords(input, test.start, test.end)
+		got := ""
+		if start < end {
+			got = string(input[start:end])
+		}
+		if got != test.want {
+			t.Errorf("snapWords(%q) = %q, want %q", input[test.start:test.end], got, test.want)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	input := testImportance
+	keywords := Parse([]byte(input)).Compact().Index(nil).Keywords(KeywordOptions{})
+	if len(keywords) == 0 {
+		t.Fatal("no keywords")
+	}
+	taken := make(map[int]string)
+	for _, k := range keywords {
+		if onlyStopWords(k.Phrase, DefaultStopWords) {
+			t.Errorf("%q is only stop words", k.Phrase)
+		}
+		if len(k.Positions) < 2 {
+			t.Errorf("%q occurs only %d times", k.Phrase, len(k.Positions))
+		}
+		for _, p := range k.Positions {
+			if !strings.HasPrefix(input[p:], k.Phrase) {
+				t.Errorf("%q not at %d", k.Phrase, p)
+			}
+			before, _ := utf8.DecodeLastRuneInString(input[:p])
+			after, _ := utf8.DecodeRuneInString(input[p+len(k.Phrase):])
+			if isWordRune(before) || isWordRune(after) {
+				t.Errorf("%q at %d is not whole words", k.Phrase, p)
+			}
+			for i := p; i < p+len(k.Phrase); i++ {
+				if other, ok := taken[i]; ok {
+					t.Errorf("%q at %d overlaps %q", k.Phrase, p, other)
+				}
+				taken[i] = k.Phrase
+			}
+		}
+	}
+}
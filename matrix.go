@@ -0,0 +1,191 @@
+package sequitur
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Similarities is a symmetric matrix of Similarity scores, storing only one triangle.
+type Similarities struct {
+	n      int
+	scores []float64 // the lower triangle including the diagonal, row by row
+}
+
+// Len gives the number of rows (and columns) in the matrix.
+func (s *Similarities) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.n
+}
+
+// At gives the Similarity between documents i and j.
+func (s *Similarities) At(i, j int) float64 {
+	if j > i {
+		i, j = j, i
+	}
+	return s.scores[i*(i+1)/2+j]
+}
+
+// Neighbour is one of the documents most similar to another.
+type Neighbour struct {
+	Index int
+	Score float64
+}
+
+// postings is an inverted index from rule strings to the (ascending) indexes of the documents containing them.
+type postings map[string][]int
+
+func newPostings(docs []*CompactIndexed) postings {
+	p := make(postings)
+	for i, ci := range docs {
+		if ci == nil {
+			continue
+		}
+		for str := range ci.StringToID {
+			p[str] = append(p[str], i)
+		}
+	}
+	return p
+}
+
+// candidates calls fn once for each document below limit which could have a non-zero Similarity with docs[i],
+// using seen (which must be as long as docs and not contain i+1) to avoid repeats.
+func (p postings) candidates(docs []*CompactIndexed, i, limit int, seen []int, fn func(j int)) {
+	ci := docs[i]
+	if ci == nil {
+		return
+	}
+	mark := func(j int) {
+		if j != i && seen[j] != i+1 {
+			seen[j] = i + 1
+			fn(j)
+		}
+	}
+	if ci.TotalCoverage == 0 {
+		// two empty grammars are equal, but have no rule strings to share
+		for j := 0; j < limit; j++ {
+			if docs[j] != nil && docs[j].TotalCoverage == 0 {
+				mark(j)
+			}
+		}
+		return
+	}
+	for str := range ci.StringToID {
+		for _, j := range p[str] {
+			if j >= limit {
+				break
+			}
+			mark(j)
+		}
+	}
+}
+
+// parallel calls fn(row, seen) for each row in [0,n) using the given number of workers (GOMAXPROCS if workers < 1).
+func parallel(n, workers int, fn func(row int, seen []int)) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seen := make([]int, n)
+			for row := range rows {
+				fn(row, seen)
+			}
+		}()
+	}
+	for row := 0; row < n; row++ {
+		rows <- row
+	}
+	close(rows)
+	wg.Wait()
+}
+
+// SimilarityMatrix gives the Similarity of every pair of docs, computed by up to workers goroutines
+// (GOMAXPROCS if workers < 1). Each pair is only compared once, and pairs which share no rule strings are not compared at all.
+func SimilarityMatrix(docs []*CompactIndexed, workers int) *Similarities {
+	n := len(docs)
+	s := &Similarities{
+		n:      n,
+		scores: make([]float64, n*(n+1)/2),
+	}
+	p := newPostings(docs)
+	parallel(n, workers, func(i int, seen []int) {
+		row := s.scores[i*(i+1)/2:]
+		row[i] = docs[i].Similarity(docs[i])
+		p.candidates(docs, i, i, seen, func(j int) {
+			row[j] = docs[i].Similarity(docs[j])
+		})
+	})
+	return s
+}
+
+// NearestNeighbours gives, for each of the docs, the k others most similar to it in descending order of Similarity,
+// computed by up to workers goroutines (GOMAXPROCS if workers < 1). Documents with a Similarity of 0 are never neighbours.
+func NearestNeighbours(docs []*CompactIndexed, k, workers int) [][]Neighbour {
+	n := len(docs)
+	ret := make([][]Neighbour, n)
+	if k < 1 {
+		return ret
+	}
+	p := newPostings(docs)
+	parallel(n, workers, func(i int, seen []int) {
+		var nn []Neighbour
+		p.candidates(docs, i, n, seen, func(j int) {
+			if score := docs[i].Similarity(docs[j]); score > 0 {
+				nn = append(nn, Neighbour{Index: j, Score: score})
+			}
+		})
+		sort.Slice(nn, func(a, b int) bool {
+			if nn[a].Score == nn[b].Score {
+				return nn[a].Index < nn[b].Index
+			}
+			return nn[a].Score > nn[b].Score
+		})
+		if len(nn) > k {
+			nn = nn[:k]
+		}
+		ret[i] = nn
+	})
+	return ret
+}
+
+// Pair is two documents and their Similarity, with A < B.
+type Pair struct {
+	A, B  int
+	Score float64
+}
+
+// SimilarPairs gives the pairs of docs with a Similarity of at least threshold, most similar first, computed by up to
+// workers goroutines (GOMAXPROCS if workers < 1). Unlike SimilarityMatrix it keeps only those pairs, so it suits large
+// collections of mostly unalike documents. Pairs which share no rule strings are not compared, so are never given.
+func SimilarPairs(docs []*CompactIndexed, threshold float64, workers int) []Pair {
+	p := newPostings(docs)
+	rows := make([][]Pair, len(docs))
+	parallel(len(docs), workers, func(i int, seen []int) {
+		p.candidates(docs, i, i, seen, func(j int) {
+			if score := docs[i].Similarity(docs[j]); score >= threshold {
+				rows[i] = append(rows[i], Pair{A: j, B: i, Score: score})
+			}
+		})
+	})
+	var pairs []Pair
+	for _, row := range rows {
+		pairs = append(pairs, row...)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
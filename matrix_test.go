@@ -0,0 +1,102 @@
+package sequitur
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo allows for Similarity summing in map order.
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func testMatrixDocs() []*CompactIndexed {
+	texts := []string{testSimilarity, testImportance, testString, "", testCompact, testString[:200], ""}
+	docs := make([]*CompactIndexed, 0, len(texts)+1)
+	for _, text := range texts {
+		docs = append(docs, Parse([]byte(text)).Compact().Index(nil))
+	}
+	return append(docs, nil)
+}
+
+func TestSimilarityMatrix(t *testing.T) {
+	docs := testMatrixDocs()
+	for _, workers := range []int{0, 1, 3} {
+		m := SimilarityMatrix(docs, workers)
+		if m.Len() != len(docs) {
+			t.Fatalf("matrix has %d rows, want %d", m.Len(), len(docs))
+		}
+		for i := range docs {
+			for j := range docs {
+				if got, want := m.At(i, j), docs[i].Similarity(docs[j]); !closeTo(got, want) {
+					t.Errorf("workers=%d At(%d,%d)=%v, want %v", workers, i, j, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestNearestNeighbours(t *testing.T) {
+	docs := testMatrixDocs()
+	nn := NearestNeighbours(docs, 2, 2)
+	if len(nn) != len(docs) {
+		t.Fatalf("got %d rows, want %d", len(nn), len(docs))
+	}
+	for i, row := range nn {
+		if len(row) > 2 {
+			t.Errorf("row %d has %d neighbours, want at most 2", i, len(row))
+		}
+		for n, nb := range row {
+			if nb.Index == i {
+				t.Errorf("row %d contains itself", i)
+			}
+			if want := docs[i].Similarity(docs[nb.Index]); !closeTo(nb.Score, want) {
+				t.Errorf("row %d neighbour %d score %v, want %v", i, nb.Index, nb.Score, want)
+			}
+			if n > 0 && row[n-1].Score < nb.Score {
+				t.Errorf("row %d not in descending order: %v", i, row)
+			}
+		}
+		// nothing left out scores higher than the last neighbour found
+		for j := range docs {
+			if j == i || len(row) < 2 {
+				continue
+			}
+			if s := docs[i].Similarity(docs[j]); s > row[len(row)-1].Score+1e-9 && j != row[0].Index && j != row[1].Index {
+				t.Errorf("row %d misses %d with score %v: %v", i, j, s, row)
+			}
+		}
+	}
+	if len(nn[3]) != 1 || nn[3][0].Index != 6 || nn[3][0].Score != 1 {
+		t.Errorf("empty documents should be each others' neighbours, got %v", nn[3])
+	}
+	if len(nn[7]) != 0 {
+		t.Errorf("nil document has neighbours %v", nn[7])
+	}
+}
+
+func TestSimilarPairs(t *testing.T) {
+	docs := testMatrixDocs()
+	for _, threshold := range []float64{0.01, 0.1, 0.5} {
+		pairs := SimilarPairs(docs, threshold, 2)
+		want := 0
+		for i := range docs {
+			for j := i + 1; j < len(docs); j++ {
+				if s := docs[i].Similarity(docs[j]); s >= threshold {
+					want++
+				}
+			}
+		}
+		if len(pairs) != want {
+			t.Errorf("threshold %v: got %d pairs, want %d", threshold, len(pairs), want)
+		}
+		for n, p := range pairs {
+			if p.A >= p.B || !closeTo(p.Score, docs[p.A].Similarity(docs[p.B])) || p.Score < threshold {
+				t.Errorf("threshold %v: bad pair %+v", threshold, p)
+			}
+			if n > 0 && pairs[n-1].Score < p.Score {
+				t.Errorf("threshold %v: pairs not in descending order", threshold)
+			}
+		}
+	}
+}
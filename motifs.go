@@ -0,0 +1,80 @@
+package sequitur
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Motif is a repeated pattern in the input of a Compact grammar.
+type Motif struct {
+	ID     SymbolID
+	Text   []byte
+	Count  int   // the number of times the rule occurs in the original input
+	Starts []int // the byte offset in the original input of each occurrence, in ascending order
+	Length int   // the length of Text in bytes
+	Depth  int   // the shallowest nesting of the rule, 1 meaning it is used directly by the root
+}
+
+// MotifOptions for Motifs.
+type MotifOptions struct {
+	MinLength          int  // the shortest motif reported, in bytes
+	MinCount           int  // the fewest occurrences of a motif reported
+	Max                int  // the most motifs reported, or all of them if zero
+	SuppressSubstrings bool // drop motifs whose Text is within the Text of a higher-ranked motif
+}
+
+// Motifs ranks the rules of the grammar by how much of the input their occurrences cover (Count×Length), most first.
+func (comp *Compact) Motifs(opts MotifOptions) []Motif {
+	byID := make(map[SymbolID]*Motif)
+	for _, o := range comp.Occurrences() {
+		m, ok := byID[o.ID]
+		if !ok {
+			m = &Motif{
+				ID:     o.ID,
+				Length: o.End - o.Start,
+				Depth:  o.Depth,
+			}
+			byID[o.ID] = m
+		}
+		m.Starts = append(m.Starts, o.Start)
+		if o.Depth < m.Depth {
+			m.Depth = o.Depth
+		}
+	}
+
+	motifs := make([]Motif, 0, len(byID))
+	for _, m := range byID {
+		m.Count = len(m.Starts)
+		if m.Length >= opts.MinLength && m.Count >= opts.MinCount {
+			motifs = append(motifs, *m)
+		}
+	}
+	sort.Slice(motifs, func(i, j int) bool {
+		a, b := motifs[i], motifs[j]
+		if ca, cb := a.Count*a.Length, b.Count*b.Length; ca != cb {
+			return ca > cb
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.ID < b.ID
+	})
+
+	kept := motifs[:0]
+outer:
+	for _, m := range motifs {
+		if opts.Max > 0 && len(kept) == opts.Max {
+			break
+		}
+		m.Text = comp.Bytes(m.ID)
+		if opts.SuppressSubstrings {
+			for _, k := range kept {
+				if bytes.Contains(k.Text, m.Text) {
+					continue outer
+				}
+			}
+		}
+		kept = append(kept, m)
+	}
+	return kept
+}
@@ -0,0 +1,62 @@
+package sequitur
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func ExampleCompact_Motifs() {
+
+	comp := Parse([]byte(testString)).Compact()
+
+	for _, m := range comp.Motifs(MotifOptions{MinLength: 4, Max: 6, SuppressSubstrings: true}) {
+		fmt.Printf("%3d %d %d %q %v\n", m.Length, m.Count, m.Depth, m.Text, m.Starts)
+	}
+
+	// Output:
+	//  28 2 1 "in the pot,\nnine days old.\n\n" [57 135]
+	//  16 3 1 "\npease porridge " [0 20 41]
+	//   9 5 1 "是十狮" [318 345 370 456 487]
+	//   4 10 1 "。\n" [227 252 280 308 360 394 428 468 514 530]
+	//  13 3 1 "some like it " [85 103 122]
+	//  17 2 1 ",\npease porridge " [19 40]
+}
+
+func TestMotifs(t *testing.T) {
+	input := []byte(testImportance)
+	comp := Parse(input).Compact()
+	all := comp.Motifs(MotifOptions{})
+	if len(all) != len(comp.Map)-1 {
+		t.Errorf("got %d motifs, want one for each of the %d rules", len(all), len(comp.Map)-1)
+	}
+	for i, m := range all {
+		if m.Count != len(m.Starts) || m.Count < 2 || m.Length != len(m.Text) {
+			t.Errorf("inconsistent motif %+v", m)
+		}
+		for _, start := range m.Starts {
+			if !bytes.Equal(input[start:start+m.Length], m.Text) {
+				t.Errorf("motif %q not at %d", m.Text, start)
+			}
+		}
+		if i > 0 && all[i-1].Count*all[i-1].Length < m.Count*m.Length {
+			t.Errorf("motif %d out of order", i)
+		}
+	}
+
+	some := comp.Motifs(MotifOptions{MinLength: 3, MinCount: 3, SuppressSubstrings: true})
+	for i, m := range some {
+		if m.Length < 3 || m.Count < 3 {
+			t.Errorf("motif %+v not filtered out", m)
+		}
+		for _, k := range some[:i] {
+			if bytes.Contains(k.Text, m.Text) {
+				t.Errorf("motif %q is within %q", m.Text, k.Text)
+			}
+		}
+	}
+
+	if got := Parse(nil).Compact().Motifs(MotifOptions{}); len(got) != 0 {
+		t.Errorf("empty grammar has motifs %v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package sequitur
+
+import "unicode/utf8"
+
+// Occurrence is one appearance of a rule in the expansion of a Compact grammar.
+type Occurrence struct {
+	ID    SymbolID
+	Start int // byte offset of the expansion in the original input
+	End   int // byte offset just after the expansion in the original input
+	Depth int // 1 for rules used directly by the root, 2 for rules used by those, and so on
+}
+
+// Occurrences lists every appearance of every rule in the expansion of the root, other than the root itself,
+// ordered by Start with enclosing rules before the rules they contain.
+func (comp *Compact) Occurrences() []Occurrence {
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return nil
+	}
+	lengths := comp.byteLengths()
+	var occ []Occurrence
+	var walk func(id SymbolID, start, depth int)
+	walk = func(id SymbolID, start, depth int) {
+		for _, sid := range comp.Map[id].IDs {
+			l := lengths[sid]
+			if sid.IsRule() {
+				occ = append(occ, Occurrence{
+					ID:    sid,
+					Start: start,
+					End:   start + l,
+					Depth: depth,
+				})
+				walk(sid, start, depth+1)
+			}
+			start += l
+		}
+	}
+	walk(comp.RootID, 0, 1)
+	return occ
+}
+
+// byteLengths gives the length of the expansion of every rule and of every terminal used by the grammar.
+func (comp *Compact) byteLengths() map[SymbolID]int {
+	lengths := make(map[SymbolID]int)
+	var length func(id SymbolID) int
+	length = func(id SymbolID) int {
+		if l, ok := lengths[id]; ok {
+			return l
+		}
+		l := 0
+		if id.IsRule() {
+			for _, sid := range comp.Map[id].IDs {
+				l += length(sid)
+			}
+		} else {
+			l = len(runeOrByte(id).appendBytes(make([]byte, 0, utf8.UTFMax)))
+		}
+		lengths[id] = l
+		return l
+	}
+	length(comp.RootID)
+	return lengths
+}
+
+// occurrenceCounts gives the number of times each rule occurs in the expansion of the root, which is 1 for the root itself.
+func (comp *Compact) occurrenceCounts() map[SymbolID]int {
+	counts := make(map[SymbolID]int)
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return counts
+	}
+	// visit the rules so that every rule comes before all of the rules it uses
+	var order SymbolIDslice
+	seen := make(map[SymbolID]bool)
+	var visit func(id SymbolID)
+	visit = func(id SymbolID) {
+		seen[id] = true
+		for _, sid := range comp.Map[id].IDs {
+			if sid.IsRule() && !seen[sid] {
+				visit(sid)
+			}
+		}
+		order = append(order, id)
+	}
+	visit(comp.RootID)
+	counts[comp.RootID] = 1
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		for _, sid := range comp.Map[id].IDs {
+			if sid.IsRule() {
+				counts[sid] += counts[id]
+			}
+		}
+	}
+	return counts
+}
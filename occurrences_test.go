@@ -0,0 +1,38 @@
+package sequitur
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOccurrences(t *testing.T) {
+	for tNum, test := range [][]byte{[]byte(testString), testBinary, []byte(testCompact), []byte(testImportance)} {
+		comp := Parse(test).Compact()
+		occ := comp.Occurrences()
+		counts := make(map[SymbolID]int)
+		for i, o := range occ {
+			if got := comp.Bytes(o.ID); !bytes.Equal(test[o.Start:o.End], got) {
+				t.Errorf("%d: occurrence %v covers %q, want %q", tNum, o, test[o.Start:o.End], got)
+			}
+			if i > 0 && occ[i-1].Start > o.Start {
+				t.Errorf("%d: occurrence %v out of order", tNum, o)
+			}
+			if o.Depth < 1 || o.ID == comp.RootID {
+				t.Errorf("%d: unexpected occurrence %v", tNum, o)
+			}
+			counts[o.ID]++
+		}
+		occCounts := comp.occurrenceCounts()
+		for id := range comp.Map {
+			if id != comp.RootID && counts[id] < 2 {
+				t.Errorf("%d: rule %v occurs %d times", tNum, id, counts[id])
+			}
+			if id != comp.RootID && occCounts[id] != counts[id] {
+				t.Errorf("%d: rule %v counted %d times, but occurs %d times", tNum, id, occCounts[id], counts[id])
+			}
+		}
+	}
+	if occ := Parse(nil).Compact().Occurrences(); occ != nil {
+		t.Errorf("empty grammar has occurrences %v", occ)
+	}
+}
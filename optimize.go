@@ -0,0 +1,202 @@
+package sequitur
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// CostModel is how Optimize measures the size of a grammar.
+type CostModel int
+
+const (
+	CostSymbols CostModel = iota // the number of symbols on the right-hand sides of the rules, as Size
+	CostBytes                    // the size of a simple encoding: terminals in UTF-8, rule numbers as varints, and a byte to end each rule
+)
+
+// OptimizeOptions control Optimize.
+type OptimizeOptions struct {
+	Cost   CostModel
+	Strict bool // only inline rules whose removal reduces the cost, keeping those which save nothing
+}
+
+// Optimization reports what Optimize did.
+type Optimization struct {
+	Merged     int // rules replaced by another with the same expansion
+	Inlined    int // rules replaced by their right-hand sides
+	Before     int // the cost of the grammar under the chosen model
+	After      int // the cost of the optimized grammar
+	BytesSaved int // the cost saved under CostBytes, whatever the model chosen
+}
+
+// Optimize gives a copy of the grammar in Canonical form which costs no more under the chosen model,
+// and reports what it did. Rules with the same expansion are merged into the one with the shortest right-hand side,
+// then working up from the rules of terminals, rules whose removal does not increase the cost are inlined.
+// The rules are judged one at a time, so the result is not necessarily the cheapest grammar with the same expansion:
+// under CostBytes in particular, inlining a rule can make references to every other rule cheaper,
+// and the rules already judged are not judged again.
+// The expansion of the root is unchanged.
+func (comp *Compact) Optimize(opts OptimizeOptions) (*Compact, Optimization) {
+	c := comp.Canonical()
+	before, beforeBytes := opts.Cost.cost(c), CostBytes.cost(c)
+	report := Optimization{Before: before, After: before}
+	if c.RootID == EmptySymbolID {
+		return c, report
+	}
+
+	// merge the rules with the same expansion, dropping any rules which only the merged rules used
+	expansion := make(map[SymbolID]string, len(c.Map))
+	for id := range c.Map {
+		if id != c.RootID {
+			expansion[id] = string(c.Bytes(id))
+		}
+	}
+	byExpansion := make(map[string]SymbolID)
+	for id, key := range expansion {
+		other, ok := byExpansion[key]
+		if n, m := len(c.Map[id].IDs), len(c.Map[other].IDs); !ok || n < m || n == m && id < other {
+			byExpansion[key] = id
+		}
+	}
+	report.Merged = len(expansion) - len(byExpansion)
+	merged := &Compact{RootID: c.RootID, Map: make(map[SymbolID]CompactEntry, len(byExpansion)+1)}
+	for id, entry := range c.Map {
+		if id != c.RootID && byExpansion[expansion[id]] != id {
+			continue
+		}
+		for i, sid := range entry.IDs {
+			if sid.IsRule() {
+				entry.IDs[i] = byExpansion[expansion[sid]]
+			}
+		}
+		merged.Map[id] = entry
+	}
+	merged = merged.Canonical()
+
+	ids := make(SymbolIDslice, 0, len(merged.Map))
+	body := make(map[SymbolID]SymbolIDslice, len(merged.Map))
+	used := make(map[SymbolID]int)
+	parents := make(map[SymbolID]SymbolIDslice)
+	for id, entry := range merged.Map {
+		ids = append(ids, id)
+		body[id] = entry.IDs
+		for _, sid := range entry.IDs {
+			if sid.IsRule() {
+				used[sid]++
+				parents[sid] = append(parents[sid], id)
+			}
+		}
+	}
+
+	// inline the rules which do not pay for themselves, children first, so that each is judged by its final right-hand side
+	rel := merged.Relations()
+	sort.Slice(ids, func(i, j int) bool {
+		if hi, hj := rel.Height(ids[i]), rel.Height(ids[j]); hi != hj {
+			return hi < hj
+		}
+		return ids[i] < ids[j]
+	})
+	for i, id := range ids {
+		if id == merged.RootID {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	for _, id := range ids {
+		// each rule inlined leaves fewer to number, so references may become cheaper
+		ref := varintLen(len(body))
+		n, bodyCost := used[id], 0
+		for _, sid := range body[id] {
+			bodyCost += opts.Cost.symbol(sid, ref)
+		}
+		keep := n*opts.Cost.symbol(id, ref) + opts.Cost.rule() + bodyCost
+		if inline := n * bodyCost; inline > keep || inline == keep && opts.Strict {
+			continue
+		}
+		report.Inlined++
+		done := make(map[SymbolID]bool)
+		for _, p := range parents[id] {
+			if done[p] {
+				continue
+			}
+			done[p] = true
+			var rhs SymbolIDslice
+			for _, sid := range body[p] {
+				if sid != id {
+					rhs = append(rhs, sid)
+					continue
+				}
+				rhs = append(rhs, body[id]...)
+				for _, s := range body[id] {
+					if s.IsRule() {
+						used[s]++
+						parents[s] = append(parents[s], p)
+					}
+				}
+			}
+			body[p] = rhs
+		}
+		for _, s := range body[id] {
+			if s.IsRule() {
+				used[s]--
+			}
+		}
+		delete(body, id)
+	}
+
+	opt := &Compact{RootID: merged.RootID, Map: make(map[SymbolID]CompactEntry, len(body))}
+	for id, rhs := range body {
+		opt.Map[id] = CompactEntry{Used: used[id], IDs: rhs}
+	}
+	opt = opt.Canonical()
+	report.After = opts.Cost.cost(opt)
+	report.BytesSaved = beforeBytes - CostBytes.cost(opt)
+	return opt, report
+}
+
+// cost of a grammar under the model.
+func (m CostModel) cost(comp *Compact) int {
+	if m == CostSymbols {
+		return comp.Size()
+	}
+	if comp == nil {
+		return 0
+	}
+	ref, total := varintLen(len(comp.Map)), 0
+	for _, entry := range comp.Map {
+		total += m.rule()
+		for _, sid := range entry.IDs {
+			total += m.symbol(sid, ref)
+		}
+	}
+	return total
+}
+
+// symbol gives the cost of a symbol on a right-hand side, where a reference to a rule costs ref under CostBytes.
+func (m CostModel) symbol(sid SymbolID, ref int) int {
+	switch {
+	case m == CostSymbols:
+		return 1
+	case sid.IsRule():
+		return ref
+	case sid < 256:
+		return 1 // a byte which is not valid UTF-8
+	}
+	return utf8.RuneLen(runeOrByte(sid).rune())
+}
+
+// rule gives the cost of a rule apart from its right-hand side.
+func (m CostModel) rule() int {
+	if m == CostSymbols {
+		return 0
+	}
+	return 1
+}
+
+// varintLen gives the number of bytes of the varint encoding of the largest of n rule numbers.
+func varintLen(n int) int {
+	l := 1
+	for n >>= 7; n > 0; n >>= 7 {
+		l++
+	}
+	return l
+}
@@ -0,0 +1,92 @@
+package sequitur
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleCompact_Optimize() {
+	comp := Parse([]byte("abcdbcabcd")).Compact()
+	opt, report := comp.Optimize(OptimizeOptions{})
+	fmt.Print(opt)
+	fmt.Printf("%+v\n", report)
+
+	// Output:
+	// 1114369 -> {0 [1114370 b c 1114370]}
+	// 1114370 -> {2 [a b c d]}
+	// {Merged:0 Inlined:1 Before:8 After:8 BytesSaved:1}
+}
+
+func TestOptimizeMerge(t *testing.T) {
+	const root = SymbolID(firstRuleID)
+	const r1, r2, r3, r4 = root + 1, root + 2, root + 3, root + 4
+	a, b, c, x := SymbolID(newRune('a')), SymbolID(newRune('b')), SymbolID(newRune('c')), SymbolID(newRune('x'))
+	comp := &Compact{
+		RootID: root,
+		Map: map[SymbolID]CompactEntry{
+			root: {IDs: SymbolIDslice{r1, r2, r1, r2, x}},
+			r1:   {Used: 2, IDs: SymbolIDslice{a, r3}},
+			r2:   {Used: 2, IDs: SymbolIDslice{r4, c}},
+			r3:   {Used: 1, IDs: SymbolIDslice{b, c}},
+			r4:   {Used: 1, IDs: SymbolIDslice{a, b}},
+		},
+	}
+	opt, report := comp.Optimize(OptimizeOptions{Strict: true})
+	want := &Compact{
+		RootID: root,
+		Map: map[SymbolID]CompactEntry{
+			root: {IDs: SymbolIDslice{r1, r1, r1, r1, x}},
+			r1:   {Used: 4, IDs: SymbolIDslice{a, b, c}},
+		},
+	}
+	if !Equal(opt, want) || opt.Map[r1].Used != 4 {
+		t.Errorf("got\n%v\nwant\n%v", opt, want)
+	}
+	if want := (Optimization{Merged: 1, Inlined: 1, Before: 13, After: 8, BytesSaved: 8}); report != want {
+		t.Errorf("got %+v, want %+v", report, want)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	inputs := []string{"", "a", "abab", testCompact, testImportance, strings.Repeat("the cat sat on the mat; ", 20), randomish(5000), "\xff\xfe\xff\xfe héllo héllo"}
+	for _, input := range inputs {
+		for _, comp := range []*Compact{Parse([]byte(input)).Compact(), RePair([]byte(input))} {
+			original := comp.String()
+			for _, opts := range []OptimizeOptions{{}, {Strict: true}, {Cost: CostBytes}, {Cost: CostBytes, Strict: true}} {
+				opt, report := comp.Optimize(opts)
+				if got := opt.Bytes(opt.RootID); string(got) != input {
+					t.Errorf("%.20q %+v: grammar expands to %.20q", input, opts, got)
+				}
+				if report.After > report.Before || report.After != opts.Cost.cost(opt) || report.Before != opts.Cost.cost(comp) {
+					t.Errorf("%.20q %+v: cost went from %d to %d", input, opts, report.Before, report.After)
+				}
+				if opts.Cost == CostBytes && report.BytesSaved != report.Before-report.After {
+					t.Errorf("%.20q %+v: saved %d bytes, but the cost went from %d to %d", input, opts, report.BytesSaved, report.Before, report.After)
+				}
+				used := make(map[SymbolID]int)
+				for _, entry := range opt.Map {
+					for _, sid := range entry.IDs {
+						if sid.IsRule() {
+							used[sid]++
+						}
+					}
+				}
+				seen := make(map[string]bool)
+				for id, entry := range opt.Map {
+					if id != opt.RootID && (entry.Used < 2 || entry.Used != used[id]) {
+						t.Errorf("%.20q %+v: rule %v is used %d times but counts %d", input, opts, id, used[id], entry.Used)
+					}
+					if e := string(opt.Bytes(id)); seen[e] {
+						t.Errorf("%.20q %+v: two rules expand to %q", input, opts, e)
+					} else {
+						seen[e] = true
+					}
+				}
+			}
+			if comp.String() != original {
+				t.Errorf("%.20q: Optimize changed the grammar", input)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package sequitur
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"unicode/utf8"
+)
+
+// DefaultProgressInterval is used when ParseOptions.ProgressInterval is zero.
+const DefaultProgressInterval = 1 << 20
+
+// checkInterval is the number of symbols ParseContext reads between checks for cancellation.
+const checkInterval = 4096
+
+// Progress of ParseContext.
+type Progress struct {
+	Bytes   int64 // the number of bytes read
+	Rules   int   // the number of rules in the grammar, not counting the top-level rule
+	Digrams int   // the number of entries in the digram table
+}
+
+// ParseOptions for ParseContext.
+type ParseOptions struct {
+	Progress         func(Progress) // if not nil, called every ProgressInterval bytes and when parsing stops
+	ProgressInterval int64          // the number of bytes between calls to Progress
+	Hooks            *Hooks         // if not nil, called as the grammar is built
+}
+
+func (g *Grammar) progress(n int64) Progress {
+	return Progress{
+		Bytes:   n,
+		Rules:   g.nrules - 1,
+		Digrams: len(g.table),
+	}
+}
+
+// ParseContext parses everything read from r, giving the same grammar as Parse would for the same bytes.
+// If ctx is cancelled, or reading fails, it returns the grammar of the input read so far along with the error.
+func ParseContext(ctx context.Context, r io.Reader, opts ParseOptions) (*Grammar, error) {
+	g := newGrammar()
+	g.hooks = opts.Hooks
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	next := interval
+	var n int64
+	if opts.Progress != nil {
+		defer func() { opts.Progress(g.progress(n)) }()
+	}
+
+	br := bufio.NewReader(r)
+	for sym := 0; ; sym++ {
+		if sym%checkInterval == 0 {
+			select {
+			case <-ctx.Done():
+				return g, ctx.Err()
+			default:
+			}
+		}
+		c, sz, err := br.ReadRune()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return g, err
+		}
+		rb := newRune(c)
+		if c == utf8.RuneError && sz == 1 {
+			_ = br.UnreadRune() // cannot fail straight after ReadRune
+			b, _ := br.ReadByte()
+			rb = newByte(b)
+		}
+		g.appendValue(uint64(rb))
+
+		n += int64(sz)
+		if opts.Progress != nil && n >= next {
+			opts.Progress(g.progress(n))
+			next = n + interval
+		}
+	}
+}
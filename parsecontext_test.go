@@ -0,0 +1,92 @@
+package sequitur
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseContext(t *testing.T) {
+	for tNum, test := range [][]byte{nil, []byte(testString), testBinary, []byte(testImportance), {0xe2, 0x82}} {
+		var calls []Progress
+		g, err := ParseContext(context.Background(), bytes.NewReader(test), ParseOptions{
+			Progress:         func(p Progress) { calls = append(calls, p) },
+			ProgressInterval: 100,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, want bytes.Buffer
+		g.PrettyPrint(&got)
+		Parse(test).PrettyPrint(&want)
+		if got.String() != want.String() {
+			t.Errorf("%d: grammar differs from Parse:\n%s\nwant:\n%s", tNum, got.String(), want.String())
+		}
+		if want := len(test)/100 + 1; len(calls) < want {
+			t.Errorf("%d: %d calls to Progress, want at least %d", tNum, len(calls), want)
+		}
+		last := calls[len(calls)-1]
+		if last.Bytes != int64(len(test)) || last.Rules != len(g.Compact().Map)-1 && len(test) > 0 || last.Digrams != len(g.table) {
+			t.Errorf("%d: final progress %+v", tNum, last)
+		}
+		for i := 1; i < len(calls); i++ {
+			if calls[i].Bytes < calls[i-1].Bytes {
+				t.Errorf("%d: progress went backwards: %v", tNum, calls)
+			}
+		}
+	}
+}
+
+// cancelReader cancels a context once it has been read past limit.
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	limit  int
+	cancel func()
+}
+
+func (cr *cancelReader) Read(p []byte) (int, error) {
+	if len(p) > 64 {
+		p = p[:64]
+	}
+	n, err := cr.r.Read(p)
+	if cr.n += n; cr.n > cr.limit {
+		cr.cancel()
+	}
+	return n, err
+}
+
+func TestParseContextCancel(t *testing.T) {
+	input := bytes.Repeat([]byte(testString), 50)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var last Progress
+	g, err := ParseContext(ctx, &cancelReader{r: bytes.NewReader(input), limit: 10000, cancel: cancel}, ParseOptions{
+		Progress: func(p Progress) { last = p },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	var b bytes.Buffer
+	g.Print(&b)
+	if b.Len() == 0 || b.Len() >= len(input) || !bytes.HasPrefix(input, b.Bytes()) {
+		t.Errorf("partial grammar has %d bytes of %d", b.Len(), len(input))
+	}
+	if last.Bytes != int64(b.Len()) {
+		t.Errorf("final progress %+v, but %d bytes parsed", last, b.Len())
+	}
+}
+
+func TestParseContextError(t *testing.T) {
+	errRead := errors.New("read failed")
+	_, err := ParseContext(context.Background(), io.MultiReader(bytes.NewReader([]byte("abc")), errReader{errRead}), ParseOptions{})
+	if err != errRead {
+		t.Errorf("got error %v, want %v", err, errRead)
+	}
+}
+
+type errReader struct{ err error }
+
+func (er errReader) Read([]byte) (int, error) { return 0, er.err }
@@ -0,0 +1,95 @@
+package sequitur
+
+// CompactCorpus is the grammar of several documents parsed together, so that they share one set of rules.
+type CompactCorpus struct {
+	Roots []SymbolIDslice           // the top-level symbols of each document
+	Map   map[SymbolID]CompactEntry // the rules used by the documents, none of which spans two documents
+}
+
+// ParseCorpus parses the documents as a single input with a separator between each of them, so that the
+// rules are shared between the documents but no rule spans two of them.
+func ParseCorpus(docs [][]byte) *CompactCorpus {
+	g := newGrammar()
+	for i, doc := range docs {
+		if i > 0 {
+			g.appendValue(separator)
+		}
+		g.appendBytes(doc)
+	}
+
+	comp := g.Compact()
+	cc := &CompactCorpus{
+		Roots: make([]SymbolIDslice, len(docs)),
+		Map:   comp.Map,
+	}
+	if comp.RootID == EmptySymbolID {
+		return cc
+	}
+	doc := 0
+	for _, sid := range comp.Map[comp.RootID].IDs {
+		if sid == separator {
+			doc++
+			continue
+		}
+		cc.Roots[doc] = append(cc.Roots[doc], sid)
+	}
+	delete(cc.Map, comp.RootID)
+	return cc
+}
+
+// Len gives the number of documents in the corpus.
+func (cc *CompactCorpus) Len() int {
+	if cc == nil {
+		return 0
+	}
+	return len(cc.Roots)
+}
+
+// Bytes of document i.
+func (cc *CompactCorpus) Bytes(i int) []byte {
+	if cc == nil || i < 0 || i >= len(cc.Roots) {
+		return nil
+	}
+	return cc.Roots[i].Bytes(&Compact{Map: cc.Map})
+}
+
+// Size of the corpus, as the total number of symbols in the roots of the documents and the right-hand sides of the rules.
+func (cc *CompactCorpus) Size() int {
+	if cc == nil {
+		return 0
+	}
+	size := 0
+	for _, root := range cc.Roots {
+		size += len(root)
+	}
+	for _, v := range cc.Map {
+		size += len(v.IDs)
+	}
+	return size
+}
+
+// Document gives a Compact grammar for document i alone, containing the shared rules it uses.
+// The Used counts of those rules are still those of the whole corpus.
+func (cc *CompactCorpus) Document(i int) *Compact {
+	comp := &Compact{
+		RootID: EmptySymbolID,
+		Map:    make(map[SymbolID]CompactEntry),
+	}
+	if cc == nil || i < 0 || i >= len(cc.Roots) || len(cc.Roots[i]) == 0 {
+		return comp
+	}
+	comp.RootID = SymbolID(firstRuleID)
+	comp.Map[comp.RootID] = CompactEntry{IDs: cc.Roots[i]}
+	var add func(ids SymbolIDslice)
+	add = func(ids SymbolIDslice) {
+		for _, sid := range ids {
+			if _, seen := comp.Map[sid]; sid.IsRule() && !seen {
+				entry := cc.Map[sid]
+				comp.Map[sid] = entry
+				add(entry.IDs)
+			}
+		}
+	}
+	add(cc.Roots[i])
+	return comp
+}
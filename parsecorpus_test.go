@@ -0,0 +1,61 @@
+package sequitur
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseCorpus(t *testing.T) {
+	docs := [][]byte{
+		[]byte(testString),
+		nil,
+		[]byte(testString[:100]),
+		[]byte("abab"),
+		[]byte("abab"),
+		testBinary,
+		[]byte(testString[50:]),
+		nil,
+	}
+	cc := ParseCorpus(docs)
+	if cc.Len() != len(docs) {
+		t.Fatalf("Len() = %d, want %d", cc.Len(), len(docs))
+	}
+	separate := 0
+	for i, doc := range docs {
+		if got := cc.Bytes(i); !bytes.Equal(got, doc) {
+			t.Errorf("document %d is %q, want %q", i, got, doc)
+		}
+		comp := cc.Document(i)
+		if got := comp.Bytes(comp.RootID); !bytes.Equal(got, doc) {
+			t.Errorf("Document(%d) is %q, want %q", i, got, doc)
+		}
+		separate += Parse(doc).Compact().Size()
+	}
+	for id, entry := range cc.Map {
+		for _, sid := range entry.IDs {
+			if sid == separator {
+				t.Errorf("rule %v contains the separator", id)
+			}
+		}
+	}
+	if cc.Size() >= separate {
+		t.Errorf("shared grammar has size %d, no smaller than %d for separate grammars", cc.Size(), separate)
+	}
+	if cc.Bytes(len(docs)) != nil || cc.Document(-1).RootID != EmptySymbolID {
+		t.Error("documents out of range are not empty")
+	}
+}
+
+func TestParseCorpusEmpty(t *testing.T) {
+	for _, docs := range [][][]byte{nil, {nil}, {nil, nil}} {
+		cc := ParseCorpus(docs)
+		if cc.Len() != len(docs) {
+			t.Errorf("Len() = %d, want %d", cc.Len(), len(docs))
+		}
+		for i := range docs {
+			if len(cc.Bytes(i)) != 0 || cc.Document(i).RootID != EmptySymbolID {
+				t.Errorf("empty document %d of %d is not empty", i, len(docs))
+			}
+		}
+	}
+}
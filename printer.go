@@ -0,0 +1,308 @@
+package sequitur
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Naming is how a Printer names rules.
+type Naming int
+
+const (
+	NameNumbers Naming = iota // 0 for the root, then 1, 2, 3 and so on, as PrettyPrint
+	NameLetters               // S for the root, then A to Z without S, AA, AB and so on
+	NameIDs                   // R followed by the SymbolID of the rule, as in the output of Compact.PrettyPrint
+)
+
+// Order is the order in which a Printer writes rules. The root always comes first.
+type Order int
+
+const (
+	OrderAppearance Order = iota // breadth first from the root, as PrettyPrint
+	OrderID                      // by ascending SymbolID
+	OrderSize                    // longest expansion first
+	OrderUsage                   // most used first
+)
+
+// Quoting is how a Printer writes terminals.
+type Quoting int
+
+const (
+	QuoteEscaped Quoting = iota // each terminal on its own, escaped as by PrettyPrint
+	QuoteStrings                // each run of terminals as a Go string literal
+)
+
+// Printer writes Compact grammars in a configurable form.
+// The zero value writes a grammar exactly as Grammar.PrettyPrint does, except that an empty grammar gives no output.
+// Rules are named in the order they appear, whatever the order they are written in,
+// and NameLetters is best used with QuoteStrings, as otherwise rules cannot be told apart from letters.
+type Printer struct {
+	Naming     Naming
+	Order      Order
+	Quoting    Quoting
+	Usage      bool // note how many times each rule other than the root is used, like the -u flag of the reference implementation
+	Expansions bool // note the expansion of each rule other than the root, like the -r flag of the reference implementation
+	Width      int  // if more than 0, wrap lines longer than this many runes, shortening expansions and splitting strings which do not fit
+
+	// Terminal, if not nil, names each terminal in place of Quoting, as for grammars of Tokens.
+	// Expansions are then written as the names of their terminals separated by spaces.
+	Terminal func(sid SymbolID) string
+}
+
+// Print writes the rules of comp to w, one to a line unless wrapped.
+func (p *Printer) Print(w io.Writer, comp *Compact) error {
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return nil
+	}
+
+	rules, names := p.names(comp)
+
+	length := make(map[SymbolID]int)
+	var expansionLength func(id SymbolID) int
+	expansionLength = func(id SymbolID) int {
+		if !id.IsRule() {
+			return 1
+		}
+		if n, ok := length[id]; ok {
+			return n
+		}
+		n := 0
+		for _, sid := range comp.Map[id].IDs {
+			n += expansionLength(sid)
+		}
+		length[id] = n
+		return n
+	}
+	var less func(a, b SymbolID) bool
+	switch p.Order {
+	case OrderID:
+		less = func(a, b SymbolID) bool { return a < b }
+	case OrderSize:
+		less = func(a, b SymbolID) bool { return expansionLength(a) > expansionLength(b) }
+	case OrderUsage:
+		less = func(a, b SymbolID) bool { return comp.Map[a].Used > comp.Map[b].Used }
+	}
+	if less != nil {
+		rest := rules[1:]
+		sort.SliceStable(rest, func(i, j int) bool { return less(rest[i], rest[j]) })
+	}
+
+	for _, id := range rules {
+		if _, err := io.WriteString(w, p.rule(comp, id, names)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RuleFormatter gives a function formatting single rules of comp as Print writes them, without the final newline.
+// The rules are named once, as they appear in comp when RuleFormatter is called.
+func (p *Printer) RuleFormatter(comp *Compact) func(id SymbolID) string {
+	q := *p
+	_, names := q.names(comp)
+	return func(id SymbolID) string {
+		return strings.TrimSuffix(q.rule(comp, id, names), "\n")
+	}
+}
+
+// names gives the rules of comp in order of appearance, and the name of each.
+func (p *Printer) names(comp *Compact) (SymbolIDslice, map[SymbolID]string) {
+	rules, number := comp.Appearance()
+	names := make(map[SymbolID]string, len(rules))
+	for _, id := range rules {
+		names[id] = p.name(id, number[id])
+	}
+	return rules, names
+}
+
+// Appearance numbers the rules breadth first from the root, as PrettyPrint does, giving them in that order
+// and the number of each, so 0 for the root. An empty grammar has no rules.
+func (comp *Compact) Appearance() (SymbolIDslice, map[SymbolID]int) {
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return nil, nil
+	}
+	rules := SymbolIDslice{comp.RootID}
+	number := map[SymbolID]int{comp.RootID: 0}
+	for i := 0; i < len(rules); i++ {
+		for _, sid := range comp.Map[rules[i]].IDs {
+			if _, ok := number[sid]; sid.IsRule() && !ok {
+				number[sid] = len(rules)
+				rules = append(rules, sid)
+			}
+		}
+	}
+	return rules, number
+}
+
+// name gives the name of the rule id, numbered n in order of appearance.
+func (p *Printer) name(id SymbolID, n int) string {
+	switch p.Naming {
+	case NameLetters:
+		if n == 0 {
+			return "S"
+		}
+		const letters = "ABCDEFGHIJKLMNOPQRTUVWXYZ"
+		var b []byte
+		for ; n > 0; n = (n - 1) / len(letters) {
+			b = append([]byte{letters[(n-1)%len(letters)]}, b...)
+		}
+		return string(b)
+	case NameIDs:
+		return "R" + strconv.FormatUint(uint64(uint32(id)), 10)
+	}
+	return strconv.Itoa(n)
+}
+
+// terminal gives the name of a terminal.
+func (p *Printer) terminal(sid SymbolID) string {
+	if p.Terminal != nil {
+		return p.Terminal(sid)
+	}
+	return string(appendPretty(nil, runeOrByte(sid)))
+}
+
+// rule formats the rule id, ending with a newline.
+func (p *Printer) rule(comp *Compact, id SymbolID, names map[SymbolID]string) string {
+	head := names[id] + " ->"
+	indent := utf8.RuneCountInString(head) + 1
+	room := -1 // for each field on a line of its own
+	if p.Width > 0 {
+		room = max(0, p.Width-indent)
+	}
+	var fields []string
+	var run []byte // terminals not yet written when quoting strings
+	for _, sid := range comp.Map[id].IDs {
+		switch {
+		case sid.IsRule():
+			if len(run) > 0 {
+				fields = append(fields, quote(run, room)...)
+				run = run[:0]
+			}
+			fields = append(fields, names[sid])
+		case p.Quoting == QuoteStrings && p.Terminal == nil:
+			run = runeOrByte(sid).appendBytes(run)
+		default:
+			fields = append(fields, p.terminal(sid))
+		}
+	}
+	if len(run) > 0 {
+		fields = append(fields, quote(run, room)...)
+	}
+
+	if id != comp.RootID && (p.Usage || p.Expansions) {
+		var note strings.Builder
+		note.WriteByte('(')
+		if p.Usage {
+			note.WriteString("×" + strconv.Itoa(comp.Map[id].Used))
+			if p.Expansions {
+				note.WriteByte(' ')
+			}
+		}
+		if p.Expansions {
+			room := -1
+			if p.Width > 0 {
+				room = max(0, p.Width-indent-utf8.RuneCountInString(note.String())-1)
+			}
+			note.WriteString(p.expansion(comp, id, room))
+		}
+		note.WriteByte(')')
+		fields = append(fields, note.String())
+	}
+
+	var b strings.Builder
+	b.WriteString(head)
+	width := indent - 1
+	for i, f := range fields {
+		n := utf8.RuneCountInString(f)
+		if p.Width > 0 && i > 0 && width+1+n > p.Width {
+			b.WriteString("\n" + strings.Repeat(" ", indent-1))
+			width = indent - 1
+		}
+		b.WriteString(" " + f)
+		width += 1 + n
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// quote quotes a run of terminals as a string literal, split into several of at most room runes each
+// (but at least one rune of the run) if room is not negative.
+func quote(run []byte, room int) []string {
+	q := strconv.Quote(string(run))
+	if room < 0 || utf8.RuneCountInString(q) <= room {
+		return []string{q}
+	}
+	var literals []string
+	out := []byte{'"'}
+	n := 1
+	for len(run) > 0 {
+		_, size := utf8.DecodeRune(run)
+		q := strconv.Quote(string(run[:size]))
+		q = q[1 : len(q)-1]
+		if m := utf8.RuneCountInString(q); n > 1 && n+m+1 > room {
+			literals = append(literals, string(out)+`"`)
+			out, n = out[:1], 1
+		}
+		out = append(out, q...)
+		n += utf8.RuneCountInString(q)
+		run = run[size:]
+	}
+	return append(literals, string(out)+`"`)
+}
+
+// expansion formats the expansion of rule id in at most room runes (though never less than "…"),
+// or without limit if room is negative.
+func (p *Printer) expansion(comp *Compact, id SymbolID, room int) string {
+	if p.Terminal != nil {
+		var names []string
+		var walk func(id SymbolID)
+		walk = func(id SymbolID) {
+			for _, sid := range comp.Map[id].IDs {
+				if sid.IsRule() {
+					walk(sid)
+				} else {
+					names = append(names, p.Terminal(sid))
+				}
+			}
+		}
+		walk(id)
+		s := strings.Join(names, " ")
+		if room >= 0 && utf8.RuneCountInString(s) > room {
+			r := []rune(s)
+			if room > 0 {
+				r = r[:room-1]
+			}
+			s = string(r) + "…"
+		}
+		return s
+	}
+
+	text := comp.Bytes(id)
+	if room < 0 {
+		return strconv.Quote(string(text))
+	}
+	if room < 3 {
+		return "…" // no room for even `""…`
+	}
+	// quote a rune at a time, so that a long expansion is not quoted in full only to be cut short
+	out := []byte{'"'}
+	n := 1
+	fit := len(out) // the longest part of out leaving room for `"…`
+	for len(text) > 0 && n <= room {
+		_, size := utf8.DecodeRune(text)
+		q := strconv.Quote(string(text[:size]))
+		out = append(out, q[1:len(q)-1]...)
+		n += utf8.RuneCountInString(q) - 2
+		text = text[size:]
+		if n+2 <= room {
+			fit = len(out)
+		}
+	}
+	if len(text) == 0 && n+1 <= room {
+		return string(out) + `"`
+	}
+	return string(out[:fit]) + `"…`
+}
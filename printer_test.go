@@ -0,0 +1,107 @@
+package sequitur
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func ExamplePrinter() {
+	comp := Parse([]byte("abcdbcabcd, abcd!")).Compact()
+	p := Printer{Naming: NameLetters, Quoting: QuoteStrings, Usage: true, Expansions: true}
+	if err := p.Print(os.Stdout, comp); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// S -> A B A ", " A "!"
+	// A -> "a" B "d" (×3 "abcd")
+	// B -> "bc" (×2 "bc")
+}
+
+func TestPrinterPrettyPrint(t *testing.T) {
+	for _, input := range []string{"a", "abcabc", testCompact, "1 2 1 2 (x) (x)\\_\\_\n\t\n\t", "\xff\xfe\xff\xfe héllo héllo"} {
+		g := Parse([]byte(input))
+		var want, got bytes.Buffer
+		if err := g.PrettyPrint(&want); err != nil {
+			t.Fatal(err)
+		}
+		if err := new(Printer).Print(&got, g.Compact()); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%q: Printer gives\n%s\nbut PrettyPrint gives\n%s", input, got.String(), want.String())
+		}
+	}
+}
+
+func TestPrinterWidth(t *testing.T) {
+	comp := Parse([]byte(strings.Repeat(testCompact+" ", 3))).Compact()
+	var longest SymbolID
+	for id := range comp.Map {
+		longest = max(longest, id)
+	}
+	// a line can be no shorter than a head and a rule name
+	name := utf8.RuneCountInString((&Printer{Naming: NameIDs}).name(longest, 0))
+	for _, quoting := range []Quoting{QuoteEscaped, QuoteStrings} {
+		for _, width := range []int{8, 12, 15, 20, 40, 80} {
+			var b bytes.Buffer
+			p := Printer{Naming: NameIDs, Order: OrderSize, Quoting: quoting, Usage: true, Expansions: true, Width: width}
+			if err := p.Print(&b, comp); err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+				if n := utf8.RuneCountInString(line); n > max(width, 2*name+len(" -> ")) {
+					t.Errorf("quoting %d width %d: line of %d runes: %q", quoting, width, n, line)
+				}
+			}
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		run  string
+		room int
+		want []string
+	}{
+		{"hello", -1, []string{`"hello"`}},
+		{"hello", 7, []string{`"hello"`}},
+		{"hello", 6, []string{`"hell"`, `"o"`}},
+		{"hello", 0, []string{`"h"`, `"e"`, `"l"`, `"l"`, `"o"`}},
+		{"a\nb\xffc", 5, []string{`"a\n"`, `"b"`, `"\xff"`, `"c"`}},
+	}
+	for _, tt := range tests {
+		if got := quote([]byte(tt.run), tt.room); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("quote(%q, %d)=%q, want %q", tt.run, tt.room, got, tt.want)
+		}
+	}
+}
+
+func TestRuleFormatter(t *testing.T) {
+	comp := Parse([]byte(testCompact)).Compact()
+	for _, p := range []Printer{{}, {Naming: NameLetters, Quoting: QuoteStrings, Usage: true, Expansions: true, Width: 40}} {
+		var want bytes.Buffer
+		if err := p.Print(&want, comp); err != nil {
+			t.Fatal(err)
+		}
+		var got strings.Builder
+		rule := p.RuleFormatter(comp)
+		rules, number := comp.Appearance()
+		for i, id := range rules {
+			if number[id] != i {
+				t.Errorf("rule %v appears at %d, but is numbered %d", id, i, number[id])
+			}
+			got.WriteString(rule(id) + "\n")
+		}
+		if got.String() != want.String() {
+			t.Errorf("%+v: formatted rules\n%s\nwant\n%s", p, got.String(), want.String())
+		}
+	}
+	if rules, _ := Parse(nil).Compact().Appearance(); rules != nil {
+		t.Errorf("empty grammar has rules %v", rules)
+	}
+}
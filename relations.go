@@ -0,0 +1,119 @@
+package sequitur
+
+import "sort"
+
+// Relations indexes the rules of a Compact grammar by the rules which use them, and by their depth and height.
+// It describes the grammar as it was when Relations was called, and is not updated if the grammar changes.
+type Relations struct {
+	parents map[SymbolID]SymbolIDslice // the rules using each rule, in ascending order
+	depth   map[SymbolID]int
+	height  map[SymbolID]int
+}
+
+// Relations builds the reverse index of the rules of the grammar.
+func (comp *Compact) Relations() *Relations {
+	rel := &Relations{
+		parents: make(map[SymbolID]SymbolIDslice),
+		depth:   make(map[SymbolID]int),
+		height:  make(map[SymbolID]int),
+	}
+	if comp == nil {
+		return rel
+	}
+	ids := make(SymbolIDslice, 0, len(comp.Map))
+	for id := range comp.Map {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		for _, sid := range comp.Map[id].IDs {
+			if p := rel.parents[sid]; sid.IsRule() && (len(p) == 0 || p[len(p)-1] != id) {
+				rel.parents[sid] = append(p, id)
+			}
+		}
+	}
+
+	// breadth first from the root, so each rule is first reached by a shortest path
+	if _, ok := comp.Map[comp.RootID]; ok {
+		rel.depth[comp.RootID] = 0
+		queue := SymbolIDslice{comp.RootID}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, sid := range comp.Map[id].IDs {
+				if _, seen := rel.depth[sid]; sid.IsRule() && !seen {
+					rel.depth[sid] = rel.depth[id] + 1
+					queue = append(queue, sid)
+				}
+			}
+		}
+	}
+
+	var height func(id SymbolID) int
+	height = func(id SymbolID) int {
+		if h, ok := rel.height[id]; ok {
+			return h
+		}
+		h := 1
+		for _, sid := range comp.Map[id].IDs {
+			if sid.IsRule() {
+				if sh := height(sid) + 1; sh > h {
+					h = sh
+				}
+			}
+		}
+		rel.height[id] = h
+		return h
+	}
+	for _, id := range ids {
+		height(id)
+	}
+	return rel
+}
+
+// Parents gives the rules which use rule id on their right-hand sides, in ascending order.
+func (rel *Relations) Parents(id SymbolID) []SymbolID {
+	if rel == nil {
+		return nil
+	}
+	return append([]SymbolID(nil), rel.parents[id]...)
+}
+
+// Depth of rule id: the fewest rules passed through on the way to it from the root, so 0 for the root itself,
+// 1 for rules used by the root, and so on. It is -1 for rules which cannot be reached from the root.
+func (rel *Relations) Depth(id SymbolID) int {
+	if rel == nil {
+		return -1
+	}
+	if d, ok := rel.depth[id]; ok {
+		return d
+	}
+	return -1
+}
+
+// Height of rule id: the most rules passed through on the way from it to a terminal, including itself,
+// so 1 for a rule of terminals only. It is 0 for terminals and for IDs which are not in the grammar.
+func (rel *Relations) Height(id SymbolID) int {
+	if rel == nil {
+		return 0
+	}
+	return rel.height[id]
+}
+
+// Parents gives the rules which use rule id on their right-hand sides, in ascending order.
+// It indexes the whole grammar on each call, so use Relations to ask about many rules.
+func (comp *Compact) Parents(id SymbolID) []SymbolID {
+	return comp.Relations().Parents(id)
+}
+
+// Depth of rule id, as given by Relations.Depth.
+// It indexes the whole grammar on each call, so use Relations to ask about many rules.
+func (comp *Compact) Depth(id SymbolID) int {
+	return comp.Relations().Depth(id)
+}
+
+// Height of rule id, as given by Relations.Height.
+// It indexes the whole grammar on each call, so use Relations to ask about many rules.
+func (comp *Compact) Height(id SymbolID) int {
+	return comp.Relations().Height(id)
+}
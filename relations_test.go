@@ -0,0 +1,87 @@
+package sequitur
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func ExampleRelations() {
+	comp := Parse([]byte("abcdbcabcd")).Compact().Canonical()
+	fmt.Print(comp)
+	rel := comp.Relations()
+	for _, id := range []SymbolID{comp.RootID, comp.RootID + 1, comp.RootID + 2} {
+		fmt.Println(id, "parents", rel.Parents(id), "depth", rel.Depth(id), "height", rel.Height(id))
+	}
+
+	// Output:
+	// 1114369 -> {0 [1114370 1114371 1114370]}
+	// 1114370 -> {2 [a 1114371 d]}
+	// 1114371 -> {2 [b c]}
+	// 1114369 parents [] depth 0 height 3
+	// 1114370 parents [1114369] depth 1 height 2
+	// 1114371 parents [1114369 1114370] depth 1 height 1
+}
+
+func TestRelations(t *testing.T) {
+	comp := Parse([]byte(testImportance)).Compact()
+	rel := comp.Relations()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id, entry := range comp.Map {
+				for _, sid := range entry.IDs {
+					if !sid.IsRule() {
+						continue
+					}
+					found := false
+					for _, p := range rel.Parents(sid) {
+						found = found || p == id
+					}
+					if !found {
+						t.Errorf("%v uses %v, but is not one of its parents %v", id, sid, rel.Parents(sid))
+					}
+					if d := rel.Depth(sid); d < 1 || d > rel.Depth(id)+1 {
+						t.Errorf("%v has depth %d, but is used by %v of depth %d", sid, d, id, rel.Depth(id))
+					}
+					if rel.Height(sid) >= rel.Height(id) {
+						t.Errorf("%v has height %d, but is used by %v of height %d", sid, rel.Height(sid), id, rel.Height(id))
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if rel.Depth(comp.RootID) != 0 || len(rel.Parents(comp.RootID)) != 0 {
+		t.Errorf("root has depth %d and parents %v", rel.Depth(comp.RootID), rel.Parents(comp.RootID))
+	}
+	if rel.Height('a'+256) != 0 || rel.Depth(-5) != -1 {
+		t.Error("terminals and unknown IDs should have height 0 and depth -1")
+	}
+	var nilComp *Compact
+	for _, r := range []*Relations{nilComp.Relations(), nil} {
+		if r.Parents(1) != nil || r.Depth(1) != -1 || r.Height(1) != 0 {
+			t.Error("nil grammar has relations")
+		}
+	}
+	if nilComp.Parents(1) != nil || nilComp.Depth(1) != -1 || nilComp.Height(1) != 0 {
+		t.Error("nil grammar has relations")
+	}
+	for id := range comp.Map {
+		if !reflect.DeepEqual(comp.Parents(id), rel.Parents(id)) || comp.Depth(id) != rel.Depth(id) || comp.Height(id) != rel.Height(id) {
+			t.Errorf("%v: Compact gives parents %v depth %d height %d, Relations gives parents %v depth %d height %d", id,
+				comp.Parents(id), comp.Depth(id), comp.Height(id), rel.Parents(id), rel.Depth(id), rel.Height(id))
+		}
+	}
+
+	// the index describes the grammar as it was
+	before := rel.Height(comp.RootID)
+	comp.Map[comp.RootID] = CompactEntry{IDs: SymbolIDslice{'a' + 256}}
+	if rel.Height(comp.RootID) != before || comp.Relations().Height(comp.RootID) != 1 {
+		t.Error("relations changed with the grammar, or a new index did not")
+	}
+}
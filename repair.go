@@ -0,0 +1,226 @@
+package sequitur
+
+import (
+	"container/heap"
+	"sort"
+	"unicode/utf8"
+)
+
+// RePair builds a grammar of input with the offline Re-Pair algorithm of Larsson and Moffat, which repeatedly
+// replaces every occurrence of the most frequent pair of adjacent symbols by a new rule until no pair occurs twice.
+// It often gives a smaller grammar than Parse, but needs all of the input at once.
+// As Sequitur would, rules which end up used only once are inlined. The grammar is in Canonical form,
+// with the same terminals as a grammar given by Parse, so it can be used wherever one of those can.
+func RePair(input []byte) *Compact {
+	seq := make(SymbolIDslice, 0, len(input))
+	for off := 0; off < len(input); {
+		r, sz := utf8.DecodeRune(input[off:])
+		rb := newRune(r)
+		if sz == 1 && r == utf8.RuneError {
+			rb = newByte(input[off])
+		}
+		seq = append(seq, SymbolID(rb))
+		off += sz
+	}
+	return rePair(seq)
+}
+
+// RePairTokens builds a grammar of tokens with RePair, each token being the terminal allocated to it by t,
+// as if the tokens had been appended to the input with t.Append.
+func RePairTokens(t *Tokens, tokens []string) (*Compact, error) {
+	seq := make(SymbolIDslice, len(tokens))
+	for i, tok := range tokens {
+		r, err := t.Rune(tok)
+		if err != nil {
+			return nil, err
+		}
+		seq[i] = SymbolID(newRune(r))
+	}
+	return rePair(seq), nil
+}
+
+// pairRecord is what is known of the occurrences of a pair of adjacent symbols.
+type pairRecord struct {
+	pair      [2]SymbolID
+	positions []int // where the pair has begun, although it may no longer do so
+	count     int   // at least the number of occurrences of the pair which do not overlap
+	seen      int   // the order in which the pairs were first seen, to break ties
+	index     int   // in the heap, or -1
+}
+
+// pairHeap gives the pair with the highest count first.
+type pairHeap []*pairRecord
+
+func (h pairHeap) Len() int { return len(h) }
+func (h pairHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count > h[j].count
+	}
+	return h[i].seen < h[j].seen
+}
+func (h pairHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *pairHeap) Push(x interface{}) {
+	rec := x.(*pairRecord)
+	rec.index = len(*h)
+	*h = append(*h, rec)
+}
+func (h *pairHeap) Pop() interface{} {
+	old := *h
+	rec := old[len(old)-1]
+	*h = old[:len(old)-1]
+	rec.index = -1
+	return rec
+}
+
+// rePairer holds the sequence being reduced as a doubly linked list over its original positions.
+type rePairer struct {
+	sym        SymbolIDslice
+	prev, next []int // -1 at the ends
+	alive      []bool
+	pairs      map[[2]SymbolID]*pairRecord
+	heap       pairHeap
+}
+
+// add records the pair beginning at position i, if there is one.
+// Counts are only ever raised here, and lowered once a pair is found to have fewer occurrences than counted,
+// so that the count at the top of the heap is always at least the most frequent pair's.
+func (rp *rePairer) add(i int) {
+	if i < 0 || rp.next[i] < 0 {
+		return
+	}
+	p := [2]SymbolID{rp.sym[i], rp.sym[rp.next[i]]}
+	rec, ok := rp.pairs[p]
+	if !ok {
+		rec = &pairRecord{pair: p, seen: len(rp.pairs), index: -1}
+		rp.pairs[p] = rec
+	}
+	rec.positions = append(rec.positions, i)
+	rec.count++
+	if rec.index < 0 {
+		heap.Push(&rp.heap, rec)
+	} else {
+		heap.Fix(&rp.heap, rec.index)
+	}
+}
+
+// occurrences gives the positions where the pair of rec begins, from left to right, skipping those overlapping
+// the occurrence before, as in a run of the same symbol. Positions where it no longer begins are forgotten.
+func (rp *rePairer) occurrences(rec *pairRecord) []int {
+	sort.Ints(rec.positions)
+	valid := rec.positions[:0]
+	for _, i := range rec.positions {
+		j := rp.next[i]
+		if !rp.alive[i] || j < 0 || rp.sym[i] != rec.pair[0] || rp.sym[j] != rec.pair[1] {
+			continue
+		}
+		if len(valid) == 0 || valid[len(valid)-1] != i {
+			valid = append(valid, i)
+		}
+	}
+	rec.positions = valid
+
+	var at []int
+	last := -1 // the position of the second symbol of the last occurrence kept
+	for _, i := range valid {
+		if i != last {
+			at = append(at, i)
+			last = rp.next[i]
+		}
+	}
+	return at
+}
+
+// replace the pair beginning at position i by id.
+func (rp *rePairer) replace(i int, id SymbolID) {
+	j := rp.next[i]
+	rp.sym[i] = id
+	rp.alive[j] = false
+	rp.next[i] = rp.next[j]
+	if rp.next[i] >= 0 {
+		rp.prev[rp.next[i]] = i
+	}
+	rp.add(rp.prev[i])
+	rp.add(i)
+}
+
+func rePair(seq SymbolIDslice) *Compact {
+	if len(seq) == 0 {
+		return (*Compact)(nil).Canonical()
+	}
+	rp := &rePairer{
+		sym:   seq,
+		prev:  make([]int, len(seq)),
+		next:  make([]int, len(seq)),
+		alive: make([]bool, len(seq)),
+		pairs: make(map[[2]SymbolID]*pairRecord),
+	}
+	for i := range seq {
+		rp.prev[i], rp.next[i], rp.alive[i] = i-1, i+1, true
+	}
+	rp.next[len(seq)-1] = -1
+	for i := range seq {
+		rp.add(i)
+	}
+
+	root := SymbolID(firstRuleID)
+	rules := make(map[SymbolID]SymbolIDslice)
+	next := root + 1
+	for len(rp.heap) > 0 && rp.heap[0].count >= 2 {
+		rec := rp.heap[0]
+		at := rp.occurrences(rec)
+		if len(at) < rec.count {
+			// counted too many, so another pair may now be more frequent
+			rec.count = len(at)
+			heap.Fix(&rp.heap, 0)
+			continue
+		}
+		heap.Pop(&rp.heap)
+		id := next
+		next++
+		rules[id] = SymbolIDslice{rec.pair[0], rec.pair[1]}
+		for _, i := range at {
+			rp.replace(i, id)
+		}
+		rec.positions, rec.count = nil, 0
+	}
+	var top SymbolIDslice
+	for i := 0; i >= 0; i = rp.next[i] {
+		top = append(top, rp.sym[i])
+	}
+	rules[root] = top
+
+	// inline the rules used once, as the pairs containing them took their other occurrences
+	used := make(map[SymbolID]int)
+	for _, ids := range rules {
+		for _, sid := range ids {
+			if sid.IsRule() {
+				used[sid]++
+			}
+		}
+	}
+	var inline func(ids SymbolIDslice, out SymbolIDslice) SymbolIDslice
+	inline = func(ids SymbolIDslice, out SymbolIDslice) SymbolIDslice {
+		for _, sid := range ids {
+			if sid.IsRule() && used[sid] == 1 {
+				out = inline(rules[sid], out)
+			} else {
+				out = append(out, sid)
+			}
+		}
+		return out
+	}
+	comp := &Compact{
+		RootID: root,
+		Map:    make(map[SymbolID]CompactEntry),
+	}
+	for id, ids := range rules {
+		if id == root || used[id] > 1 {
+			comp.Map[id] = CompactEntry{Used: used[id], IDs: inline(ids, nil)}
+		}
+	}
+	return comp.Canonical()
+}
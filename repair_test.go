@@ -0,0 +1,86 @@
+package sequitur
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleRePair() {
+	comp := RePair([]byte("abcdbcabcd"))
+	fmt.Print(comp)
+
+	// Output:
+	// 1114369 -> {0 [1114370 1114371 1114370]}
+	// 1114370 -> {2 [a 1114371 d]}
+	// 1114371 -> {2 [b c]}
+}
+
+func TestRePair(t *testing.T) {
+	for _, input := range []string{"", "a", "aa", "aaa", "aaaaaaaaaaa", "abababab", "\xff\xfe\xff\xfe héllo héllo", testCompact, testImportance,
+		strings.Repeat("the cat sat on the mat; ", 20), randomish(5000)} {
+		comp := RePair([]byte(input))
+		if got := comp.Bytes(comp.RootID); string(got) != input {
+			t.Errorf("%.20q: grammar expands to %.20q", input, got)
+			continue
+		}
+		if !Equal(comp, comp.Canonical()) {
+			t.Errorf("%.20q: grammar is not canonical", input)
+		}
+		used := make(map[SymbolID]int)
+		for _, entry := range comp.Map {
+			for _, sid := range entry.IDs {
+				if sid.IsRule() {
+					used[sid]++
+				}
+			}
+		}
+		for id, entry := range comp.Map {
+			if id != comp.RootID && (entry.Used < 2 || entry.Used != used[id]) {
+				t.Errorf("%.20q: rule %v is used %d times but counts %d", input, id, used[id], entry.Used)
+			}
+		}
+		if len(input) > 100 {
+			if rs, ss := comp.Size(), Parse([]byte(input)).Compact().Size(); rs > ss {
+				t.Errorf("%.20q: Re-Pair grammar of %d symbols is larger than Sequitur's of %d", input, rs, ss)
+			}
+		}
+	}
+}
+
+// randomish gives n bytes of text with repeats at several scales.
+func randomish(n int) string {
+	words := strings.Fields("grammar rule symbol digram pair token input the a of")
+	var b bytes.Buffer
+	for x := uint32(1); b.Len() < n; {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		b.WriteString(words[x%uint32(len(words))])
+		b.WriteString(" ,."[x>>8%3 : x>>8%3+1])
+	}
+	return b.String()[:n]
+}
+
+func TestRePairTokens(t *testing.T) {
+	words := strings.Fields("the cat sat on the mat the cat sat on the hat")
+	var toks Tokens
+	comp, err := RePairTokens(&toks, words)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input []byte
+	var want Tokens
+	for _, w := range words {
+		if input, err = want.Append(input, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(comp.Bytes(comp.RootID), input) {
+		t.Errorf("grammar expands to %q, want %q", comp.Bytes(comp.RootID), input)
+	}
+	if toks.Len() != 6 || len(comp.Map) != 2 {
+		t.Errorf("%d tokens and grammar\n%v", toks.Len(), comp)
+	}
+}
@@ -0,0 +1,61 @@
+package sequitur
+
+import "bytes"
+
+// RuleString is a rule string ranked by CompactIndexed.RuleStrings.
+type RuleString struct {
+	String   string  `json:"string"`
+	Score    float64 `json:"score"`
+	Coverage float64 `json:"coverage"` // the proportion of the input represented by the rule
+	Used     int     `json:"used"`     // the number of times the rule is used in the grammar
+}
+
+// KeepLength gives a filter for Compact.Index keeping the rule strings of minLength to maxLength bytes
+// (without limit if maxLength is 0), measured after trimming white space from their ends if trim is true.
+func KeepLength(minLength, maxLength int, trim bool) func([]byte) bool {
+	return func(b []byte) bool {
+		if trim {
+			b = bytes.TrimSpace(b)
+		}
+		return len(b) >= minLength && (maxLength == 0 || len(b) <= maxLength)
+	}
+}
+
+// UsedScore is a scoring function for Importance favouring the rules used most in the grammar:
+// the coverage of rule sid times the square of its CompactEntry.Used.
+func (ci *CompactIndexed) UsedScore(sid SymbolID) float64 {
+	u := ci.CompactBasis.Map[sid].Used
+	return ci.IDinfo[sid].Coverage * float64(u*u)
+}
+
+// RuleStrings gives the strings of the first n of the ranked rules (all of them if n is 0), as given by Importance
+// or Corpus.Importance, trimming white space from their ends if trim is true. The root is skipped,
+// as is any rule whose string was already given by a higher ranked rule.
+func (ci *CompactIndexed) RuleStrings(ranked []Importance, n int, trim bool) []RuleString {
+	strs := []RuleString{}
+	if ci == nil {
+		return strs
+	}
+	comp := ci.CompactBasis
+	seen := make(map[string]bool)
+	for _, imp := range ranked {
+		if n > 0 && len(strs) == n {
+			break
+		}
+		str := comp.Bytes(imp.ID)
+		if trim {
+			str = bytes.TrimSpace(str)
+		}
+		if seen[string(str)] || imp.ID == comp.RootID {
+			continue
+		}
+		seen[string(str)] = true
+		strs = append(strs, RuleString{
+			String:   string(str),
+			Score:    imp.Score,
+			Coverage: ci.IDinfo[imp.ID].Coverage,
+			Used:     comp.Map[imp.ID].Used,
+		})
+	}
+	return strs
+}
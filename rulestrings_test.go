@@ -0,0 +1,59 @@
+package sequitur
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleCompactIndexed_RuleStrings() {
+	ci := Parse([]byte(testImportance)).Compact().Index(KeepLength(5, 25, true))
+	for k, v := range ci.RuleStrings(ci.Importance(ci.UsedScore), 5, true) {
+		fmt.Printf("%d %7.5f %s\n", k, v.Score, v.String)
+	}
+
+	// Output:
+	// 0 0.05730 algorithm
+	// 1 0.04456 grammar
+	// 2 0.04125 nonterminal symbol
+	// 3 0.03667 sequence
+	// 4 0.03209 in the grammar
+}
+
+func TestRuleStrings(t *testing.T) {
+	comp := Parse([]byte("abc abc  abc\tabc ")).Compact()
+	ci := comp.Index(nil)
+	ranked := ci.Importance(nil)
+	for _, trim := range []bool{false, true} {
+		strs := ci.RuleStrings(ranked, 0, trim)
+		seen := make(map[string]bool)
+		for _, s := range strs {
+			if seen[s.String] {
+				t.Errorf("trim %v: %q given twice", trim, s.String)
+			}
+			seen[s.String] = true
+		}
+		if seen[string(comp.Bytes(comp.RootID))] {
+			t.Errorf("trim %v: root given", trim)
+		}
+		if trim && !seen["abc"] {
+			t.Errorf("trimmed strings %+v lack abc", strs)
+		}
+		if got := ci.RuleStrings(ranked, 1, trim); len(got) != 1 || got[0] != strs[0] {
+			t.Errorf("trim %v: first string %+v, want %+v", trim, got, strs[0])
+		}
+	}
+	var nilIndex *CompactIndexed
+	if strs := nilIndex.RuleStrings(nil, 0, true); strs == nil || len(strs) != 0 {
+		t.Errorf("nil index gives %#v, want an empty slice", strs)
+	}
+
+	keep := KeepLength(2, 3, true)
+	for s, want := range map[string]bool{"a": false, " ab ": true, "abc": true, "abcd": false, "   ": false} {
+		if keep([]byte(s)) != want {
+			t.Errorf("KeepLength(2, 3, true)(%q)=%v, want %v", s, !want, want)
+		}
+	}
+	if !KeepLength(1, 0, false)([]byte(" ")) {
+		t.Error("untrimmed space too short for KeepLength(1, 0, false)")
+	}
+}
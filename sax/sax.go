@@ -0,0 +1,167 @@
+// Package sax discretizes numeric series using Symbolic Aggregate approXimation (SAX), so that sequitur can find
+// structure in them.
+/*
+	https://www.cs.ucr.edu/~eamonn/SAX.htm
+*/
+package sax
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// DefaultNormThreshold is used when Options.NormThreshold is zero.
+const DefaultNormThreshold = 0.01
+
+// Options for Discretize.
+type Options struct {
+	Window              int     // the number of points in each sliding window, or zero for the whole series as one window
+	PAA                 int     // the number of segments each window is reduced to, which is the length of each word
+	Alphabet            int     // the number of letters used in each word, from 2 to 26
+	NormThreshold       float64 // windows with a standard deviation below this are only centred, not scaled
+	NumerosityReduction bool    // drop words identical to the word before them
+}
+
+// Discretized is a series converted into SAX words.
+type Discretized struct {
+	Words   []string // the SAX word for each window kept
+	Offsets []int    // the index in the series of the first point of the window of each word
+	Window  int      // the number of points in each window
+	Input   []byte   // the words encoded for sequitur.Parse, one terminal symbol per word
+
+	length int   // the number of points in the series
+	starts []int // the byte offset in Input of each word
+	tokens sequitur.Tokens
+}
+
+// Discretize converts a series into SAX words, one for each sliding window.
+func Discretize(series []float64, opts Options) (*Discretized, error) {
+	window := opts.Window
+	if window == 0 {
+		window = len(series)
+	}
+	switch {
+	case window < 1 || window > len(series):
+		return nil, errors.New("sax: window must be between 1 and the length of the series")
+	case opts.PAA < 1 || opts.PAA > window:
+		return nil, errors.New("sax: PAA must be between 1 and the window size")
+	case opts.Alphabet < 2 || opts.Alphabet > 26:
+		return nil, errors.New("sax: alphabet size must be between 2 and 26")
+	}
+	threshold := opts.NormThreshold
+	if threshold == 0 {
+		threshold = DefaultNormThreshold
+	}
+
+	d := &Discretized{
+		Window: window,
+		length: len(series),
+	}
+	cuts := Breakpoints(opts.Alphabet)
+	norm := make([]float64, window)
+	word := make([]byte, opts.PAA)
+	for off := 0; off+window <= len(series); off++ {
+		znorm(norm, series[off:off+window], threshold)
+		for i, v := range PAA(norm, opts.PAA) {
+			word[i] = 'a' + byte(sort.SearchFloat64s(cuts, v))
+		}
+		w := string(word)
+		if opts.NumerosityReduction && len(d.Words) > 0 && d.Words[len(d.Words)-1] == w {
+			continue
+		}
+		d.starts = append(d.starts, len(d.Input))
+		var err error
+		if d.Input, err = d.tokens.Append(d.Input, w); err != nil {
+			return nil, err
+		}
+		d.Words = append(d.Words, w)
+		d.Offsets = append(d.Offsets, off)
+	}
+	return d, nil
+}
+
+// Breakpoints divides the standard normal distribution into alphabet equally probable regions.
+func Breakpoints(alphabet int) []float64 {
+	cuts := make([]float64, alphabet-1)
+	for i := range cuts {
+		cuts[i] = math.Sqrt2 * math.Erfinv(2*float64(i+1)/float64(alphabet)-1)
+	}
+	return cuts
+}
+
+// znorm sets dst to src normalized to a mean of 0 and a standard deviation of 1, or just centred on 0
+// if the standard deviation is below threshold.
+func znorm(dst, src []float64, threshold float64) {
+	mean, sq := 0.0, 0.0
+	for _, v := range src {
+		mean += v
+	}
+	mean /= float64(len(src))
+	for _, v := range src {
+		sq += (v - mean) * (v - mean)
+	}
+	sd := math.Sqrt(sq / float64(len(src)))
+	if sd < threshold {
+		sd = 1
+	}
+	for i, v := range src {
+		dst[i] = (v - mean) / sd
+	}
+}
+
+// PAA reduces series to the mean of each of segments equal parts, dividing points between segments where
+// the length of series is not a multiple of segments.
+func PAA(series []float64, segments int) []float64 {
+	n := len(series)
+	out := make([]float64, segments)
+	// in units of 1/segments of a point, point i spans [i*segments, (i+1)*segments) and segment j spans [j*n, (j+1)*n)
+	for j := range out {
+		lo, hi := j*n, (j+1)*n
+		sum := 0.0
+		for i := lo / segments; i < n && i*segments < hi; i++ {
+			overlap := min((i+1)*segments, hi) - max(i*segments, lo)
+			sum += series[i] * float64(overlap)
+		}
+		out[j] = sum / float64(n)
+	}
+	return out
+}
+
+// Parse builds the grammar of the words.
+func (d *Discretized) Parse() *sequitur.Grammar {
+	return sequitur.Parse(d.Input)
+}
+
+// Word gives the SAX word represented by a terminal SymbolID of the grammar.
+func (d *Discretized) Word(sid sequitur.SymbolID) (string, bool) {
+	return d.tokens.Token(sid)
+}
+
+// Interval maps an occurrence of a rule in the grammar of the words back to the points of the series it covers,
+// from start up to but not including end.
+func (d *Discretized) Interval(o sequitur.Occurrence) (start, end int) {
+	return d.Points(sort.SearchInts(d.starts, o.Start), sort.SearchInts(d.starts, o.End))
+}
+
+// Points maps the words from i up to but not including j, as indexes into Words such as those of
+// an anomaly.Interval, back to the points of the series they cover, from start up to but not including end.
+func (d *Discretized) Points(i, j int) (start, end int) {
+	first, last := i, min(j, len(d.Offsets))-1
+	if first < 0 || first >= len(d.Offsets) || last < first {
+		return 0, 0
+	}
+	start = d.Offsets[first]
+	if last+1 < len(d.Offsets) {
+		// a word stands for the run of identical words dropped by numerosity reduction after it
+		end = d.Offsets[last+1] - 1 + d.Window
+	} else {
+		end = d.length
+	}
+	if end > d.length {
+		end = d.length
+	}
+	return start, end
+}
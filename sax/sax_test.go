@@ -0,0 +1,102 @@
+package sax
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dgryski/go-sequitur/anomaly"
+)
+
+func ExampleDiscretize() {
+	series := []float64{1, 2, 3, 4, 3, 2, 1, 2, 3, 4, 3, 2, 1, 2, 3, 4, 3, 2, 1}
+	d, err := Discretize(series, Options{Window: 6, PAA: 3, Alphabet: 3, NumerosityReduction: true})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(d.Words)
+	fmt.Println(d.Offsets)
+
+	// Output:
+	// [acb bca cba cab bac abc acb bca cba cab bac abc acb bca]
+	// [0 1 2 3 4 5 6 7 8 9 10 11 12 13]
+}
+
+func TestPAA(t *testing.T) {
+	for _, test := range []struct {
+		series   []float64
+		segments int
+		want     []float64
+	}{
+		{[]float64{1, 2, 3, 4}, 2, []float64{1.5, 3.5}},
+		{[]float64{1, 2, 3, 4}, 4, []float64{1, 2, 3, 4}},
+		{[]float64{3, 3, 6}, 2, []float64{3, 5}},
+		{[]float64{1, 2, 3, 4, 5}, 1, []float64{3}},
+	} {
+		if got := PAA(test.series, test.segments); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("PAA(%v, %d) = %v, want %v", test.series, test.segments, got, test.want)
+		}
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	want := []float64{-0.67449, 0, 0.67449}
+	for i, got := range Breakpoints(4) {
+		if math.Abs(got-want[i]) > 1e-5 {
+			t.Errorf("breakpoint %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestDiscretizeErrors(t *testing.T) {
+	series := []float64{1, 2, 3, 4}
+	for _, opts := range []Options{
+		{Window: 5, PAA: 2, Alphabet: 3},
+		{Window: 2, PAA: 3, Alphabet: 3},
+		{Window: 2, PAA: 2, Alphabet: 1},
+		{Window: 2, PAA: 2, Alphabet: 27},
+	} {
+		if _, err := Discretize(series, opts); err == nil {
+			t.Errorf("no error for %+v", opts)
+		}
+	}
+}
+
+func TestAnomalyInterval(t *testing.T) {
+	series := make([]float64, 400)
+	for i := range series {
+		series[i] = math.Sin(float64(i) * 2 * math.Pi / 25)
+	}
+	for i := 200; i < 215; i++ {
+		series[i] = 0.3
+	}
+	d, err := Discretize(series, Options{Window: 25, PAA: 4, Alphabet: 4, NumerosityReduction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comp := d.Parse().Compact()
+	for _, o := range comp.Occurrences() {
+		start, end := d.Interval(o)
+		if start >= end || start < 0 || end > len(series) {
+			t.Errorf("occurrence %v maps to bad interval %d-%d", o, start, end)
+		}
+	}
+	if curve := anomaly.Density(comp, comp.Occurrences()); len(curve) != len(d.Words) {
+		t.Errorf("density of %d symbols for %d words", len(curve), len(d.Words))
+	}
+	found := anomaly.Detect(comp, anomaly.Options{Max: 1})
+	if len(found) != 1 {
+		t.Fatalf("found %d anomalies, want 1", len(found))
+	}
+	start, end := d.Points(found[0].Start, found[0].End)
+	if start > 200 || end < 215 || end-start > 3*25 {
+		t.Errorf("anomaly of words %d-%d at %d-%d does not cover 200-215 closely", found[0].Start, found[0].End, start, end)
+	}
+	if start, end := d.Points(0, len(d.Words)); start != 0 || end != len(series) {
+		t.Errorf("all of the words cover %d-%d, want 0-%d", start, end, len(series))
+	}
+	if start, end := d.Points(len(d.Words), len(d.Words)+1); start != 0 || end != 0 {
+		t.Errorf("words past the end cover %d-%d", start, end)
+	}
+}
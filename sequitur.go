@@ -18,6 +18,8 @@ type Grammar struct {
 	table  digrams
 	base   *rules
 	ruleID uint64
+	nrules int // the number of rules in use, including base
+	hooks  *Hooks
 }
 
 func (g *Grammar) nextID() uint64 {
@@ -37,6 +39,7 @@ func (r *rules) last() *symbols  { return r.guard.prev }
 func (g *Grammar) newRules() *rules {
 	r := &rules{id: g.nextID()}
 	r.guard = g.newGuard(r)
+	g.nrules++
 	return r
 }
 
@@ -78,6 +81,7 @@ type symbols struct {
 
 func (s *symbols) isGuard() (b bool)   { return s.isNonTerminal() && s.rule.first().prev == s }
 func (s *symbols) isNonTerminal() bool { return s.rule != nil }
+func (s *symbols) isSeparator() bool   { return !s.isNonTerminal() && s.value == separator }
 
 func (s *symbols) delete() {
 	s.prev.join(s.next)
@@ -122,7 +126,7 @@ func (s *symbols) deleteDigram() {
 }
 
 func (s *symbols) check() bool {
-	if s.isGuard() || s.next.isGuard() {
+	if s.isGuard() || s.next.isGuard() || s.isSeparator() || s.next.isSeparator() {
 		return false
 	}
 
@@ -151,6 +155,8 @@ func (s *symbols) expand() {
 	l.join(right)
 
 	s.g.table.insert(l)
+	s.g.nrules--
+	s.g.ruleInlined(s.rule)
 }
 
 func (s *symbols) substitute(r *rules) {
@@ -172,6 +178,7 @@ func (s *symbols) match(m *symbols) {
 	if m.prev.isGuard() && m.next.next.isGuard() {
 		r = m.prev.rule
 		s.substitute(r)
+		s.g.digramReplaced(r)
 	} else {
 		r = s.g.newRules()
 
@@ -182,6 +189,7 @@ func (s *symbols) match(m *symbols) {
 		s.substitute(r)
 
 		s.g.table.insert(r.first())
+		s.g.ruleCreated(r)
 	}
 
 	if r.first().isNonTerminal() && r.first().rule.count == 1 {
@@ -250,7 +258,13 @@ func (pr *prettyPrinter) printNonTerminal(w io.Writer, r *rules) error {
 func (pr *prettyPrinter) printTerminal(w io.Writer, sym uint64) error {
 	out := make([]byte, 1, 1+utf8.UTFMax)
 	out[0] = ' '
-	rb := runeOrByte(sym)
+	out = appendPretty(out, runeOrByte(sym))
+	_, err := w.Write(out)
+	return err
+}
+
+// appendPretty appends a terminal as it appears in the output of PrettyPrint.
+func appendPretty(out []byte, rb runeOrByte) []byte {
 	switch r := rb.rune(); r {
 	case ' ':
 		out = append(out, '_')
@@ -263,8 +277,7 @@ func (pr *prettyPrinter) printTerminal(w io.Writer, sym uint64) error {
 	default:
 		out = rb.appendEscaped(out)
 	}
-	_, err := w.Write(out)
-	return err
+	return out
 }
 
 func rawPrint(w io.Writer, r *rules) error {
@@ -308,13 +321,27 @@ func (g *Grammar) PrettyPrint(w io.Writer) error {
 	return nil
 }
 
-// Parse parses the given bytes.
-func Parse(str []byte) *Grammar {
-	g := &Grammar{
-		ruleID: maxRuneOrByte + 1,
-		table:  make(digrams),
-	}
+func newGrammar() *Grammar {
+	g := &Grammar{}
+	g.init()
+	return g
+}
+
+// init an empty grammar, keeping its hooks.
+func (g *Grammar) init() {
+	g.ruleID = maxRuneOrByte + 1
+	g.table = make(digrams)
 	g.base = g.newRules()
+}
+
+// appendValue adds a terminal to the end of the top-level rule.
+func (g *Grammar) appendValue(v uint64) {
+	g.base.last().insertAfter(g.newSymbolFromValue(v))
+	g.base.last().prev.check()
+	g.symbolAppended(v)
+}
+
+func (g *Grammar) appendBytes(str []byte) {
 	for off := 0; off < len(str); {
 		var rb runeOrByte
 		r, sz := utf8.DecodeRune(str[off:])
@@ -323,15 +350,32 @@ func Parse(str []byte) *Grammar {
 		} else {
 			rb = newRune(r)
 		}
-		g.base.last().insertAfter(g.newSymbolFromValue(uint64(rb)))
-		if off > 0 {
-			g.base.last().prev.check()
-		}
+		g.appendValue(uint64(rb))
 		off += sz
 	}
+}
+
+// Parse parses the given bytes.
+func Parse(str []byte) *Grammar {
+	g := newGrammar()
+	g.appendBytes(str)
 	return g
 }
 
+// Append parses str as if it followed the input already parsed, so that Parse(a) followed by Append(b)
+// gives the same grammar as Parse of a and b together, unless a rune is split between them.
+// With SetHooks, it lets the steps of Parse be observed:
+//
+//	g := Parse(nil)
+//	g.SetHooks(hooks)
+//	g.Append(input)
+func (g *Grammar) Append(str []byte) {
+	if g.base == nil {
+		g.init()
+	}
+	g.appendBytes(str)
+}
+
 // runeOrByte holds a rune or a byte so that we can distinguish between
 // bytes that don't represent valid UTF-8 and all other runes. Values
 // not representable as UTF-8 are in the range 128-255. All other
@@ -341,6 +385,11 @@ type runeOrByte rune
 
 const maxRuneOrByte = uint64(utf8.MaxRune) + 256 // larger than the largest possible value of runeOrByte
 
+const firstRuleID = maxRuneOrByte + 2 // the ID of the top-level rule of a parsed grammar
+
+// separator is a terminal which is not a runeOrByte. It never forms part of a digram, so no rule can contain it.
+const separator = 0
+
 func newRune(r rune) runeOrByte {
 	return runeOrByte(r + 256)
 }
@@ -0,0 +1,53 @@
+package sequitur
+
+// Shape describes the size and shape of a Compact grammar, counting only the rules used by the root.
+type Shape struct {
+	Symbols    int     // the number of terminals in the input
+	Distinct   int     // the number of different terminals
+	Rules      int     // not counting the root
+	RootLength int     // the number of symbols in the root
+	Size       int     // the number of symbols in all of the rules
+	Ratio      float64 // Size divided by Symbols
+	Depth      int     // the deepest nesting of rules, 1 if the root uses no others
+}
+
+// Shape gives the Shape of comp, which must pass Check.
+func (comp *Compact) Shape() Shape {
+	var s Shape
+	if comp == nil || comp.RootID == EmptySymbolID {
+		return s
+	}
+	symbols := make(map[SymbolID]int)
+	depths := make(map[SymbolID]int)
+	distinct := make(map[SymbolID]bool)
+	var walk func(id SymbolID)
+	walk = func(id SymbolID) {
+		ids := comp.Map[id].IDs
+		n, depth := 0, 1
+		for _, sid := range ids {
+			if !sid.IsRule() {
+				distinct[sid] = true
+				n++
+				continue
+			}
+			if _, ok := depths[sid]; !ok {
+				walk(sid)
+			}
+			n += symbols[sid]
+			if depths[sid]+1 > depth {
+				depth = depths[sid] + 1
+			}
+		}
+		symbols[id], depths[id] = n, depth
+		s.Size += len(ids)
+	}
+	walk(comp.RootID)
+	s.Symbols, s.Depth = symbols[comp.RootID], depths[comp.RootID]
+	s.Distinct = len(distinct)
+	s.Rules = len(depths) - 1
+	s.RootLength = len(comp.Map[comp.RootID].IDs)
+	if s.Symbols > 0 {
+		s.Ratio = float64(s.Size) / float64(s.Symbols)
+	}
+	return s
+}
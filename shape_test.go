@@ -0,0 +1,14 @@
+package sequitur
+
+import "testing"
+
+func TestShape(t *testing.T) {
+	comp := Parse([]byte("abcdbcabcd")).Compact()
+	want := Shape{Symbols: 10, Distinct: 4, Rules: 2, RootLength: 3, Size: 8, Ratio: 0.8, Depth: 3}
+	if got := comp.Shape(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := Parse(nil).Compact().Shape(); got != (Shape{}) {
+		t.Errorf("empty grammar has shape %+v", got)
+	}
+}
@@ -0,0 +1,176 @@
+package sequitur
+
+import (
+	"bytes"
+	"math"
+)
+
+// SimilarityMeasure selects how SimilarityBy compares two CompactIndexed grammars.
+type SimilarityMeasure int
+
+const (
+	// CoverageOverlap is the measure used by Similarity: the coverage of the rule strings found in both grammars,
+	// divided by the total coverage of both. Range 0 to 1, symmetric.
+	CoverageOverlap SimilarityMeasure = iota
+
+	// Jaccard is the number of rule strings found in both grammars, divided by the number found in either.
+	// Range 0 to 1, symmetric.
+	Jaccard
+
+	// UsageCosine is the cosine of the angle between the two grammars' vectors of rule strings,
+	// each weighted by the CompactEntry.Used of its rule. Range 0 to 1, symmetric.
+	UsageCosine
+
+	// Containment is the proportion of the coverage of the receiver made up of rule strings also found in the argument,
+	// so it answers "how much of A is in B?". Range 0 to 1, asymmetric.
+	Containment
+
+	// Compression is one minus the CompressionDistance of the two underlying Compact grammars, normalised so that
+	// identical inputs give 1: Sequitur only gradually finds that the second half of a concatenation repeats the first,
+	// so the cost of concatenating the smaller grammar's input with itself is taken off both the concatenation of
+	// the two and the larger grammar. Range 0 to 1 (after clamping), only approximately symmetric, as the grammar
+	// of A+B may differ in size from that of B+A. Inputs so short that the repeat costs as much as their grammar
+	// are compared directly, giving 1 if equal and 0 if not.
+	Compression
+)
+
+// String for SimilarityMeasure.
+func (m SimilarityMeasure) String() string {
+	switch m {
+	case CoverageOverlap:
+		return "coverage"
+	case Jaccard:
+		return "jaccard"
+	case UsageCosine:
+		return "cosine"
+	case Containment:
+		return "containment"
+	case Compression:
+		return "compression"
+	}
+	return "unknown"
+}
+
+// SimilarityBy compares two CompactIndexed grammars using the given measure. Result: 1 (or nearby) equality, 0 inequality.
+// As with Similarity, two empty grammars are considered equal and a nil grammar is similar to nothing.
+func (ci *CompactIndexed) SimilarityBy(ci2 *CompactIndexed, m SimilarityMeasure) float64 {
+	if ci == nil || ci2 == nil {
+		return 0
+	}
+	switch m {
+	case CoverageOverlap:
+		return ci.Similarity(ci2)
+	case Jaccard:
+		return ci.jaccard(ci2)
+	case UsageCosine:
+		return ci.usageCosine(ci2)
+	case Containment:
+		return ci.containment(ci2)
+	case Compression:
+		return ci.compression(ci2)
+	}
+	return 0
+}
+
+func (ci *CompactIndexed) jaccard(ci2 *CompactIndexed) float64 {
+	if len(ci2.StringToID) < len(ci.StringToID) {
+		ci2, ci = ci, ci2 // swap to iterate over the shorter
+	}
+	shared := 0
+	for str := range ci.StringToID {
+		if _, found := ci2.StringToID[str]; found {
+			shared++
+		}
+	}
+	union := len(ci.StringToID) + len(ci2.StringToID) - shared
+	if union == 0 {
+		return 1 // two empty grammars are equal
+	}
+	return float64(shared) / float64(union)
+}
+
+func (ci *CompactIndexed) compression(ci2 *CompactIndexed) float64 {
+	a, b := ci.CompactBasis, ci2.CompactBasis
+	lo, hi, small := a.Size(), b.Size(), a
+	if lo > hi {
+		lo, hi, small = hi, lo, b
+	}
+	if hi == 0 {
+		return 1 // two empty grammars are equal
+	}
+	self := concatenatedSize(small, small) - lo
+	if self >= hi {
+		// a grammar of a few symbols costs no more than its repeat, so the sizes cannot tell the inputs apart
+		if bytes.Equal(a.Bytes(a.RootID), b.Bytes(b.RootID)) {
+			return 1
+		}
+		return 0
+	}
+	distance := float64(concatenatedSize(a, b)-lo-self) / float64(hi-self)
+	return 1 - math.Max(0, math.Min(1, distance))
+}
+
+func (ci *CompactIndexed) usageCosine(ci2 *CompactIndexed) float64 {
+	// weight each rule string by the uses of all of the rules with that expansion
+	terms, terms2 := ci.terms(), ci2.terms()
+	dot, norm, norm2 := 0.0, 0.0, 0.0
+	for str, t := range terms {
+		u := float64(t.used)
+		norm += u * u
+		dot += u * float64(terms2[str].used)
+	}
+	for _, t := range terms2 {
+		u := float64(t.used)
+		norm2 += u * u
+	}
+	if ci.OriginalInputLength == 0 && ci2.OriginalInputLength == 0 {
+		return 1 // two empty grammars are equal
+	}
+	if norm == 0 || norm2 == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(norm*norm2)
+}
+
+func (ci *CompactIndexed) containment(ci2 *CompactIndexed) float64 {
+	// the coverage of each rule string, rather than of each rule, so that rules with the same expansion count once
+	total, contained := 0.0, 0.0
+	for str := range ci.StringToID {
+		coverage := float64(len(str)) / float64(ci.OriginalInputLength)
+		total += coverage
+		if _, found := ci2.StringToID[str]; found {
+			contained += coverage
+		}
+	}
+	if total == 0 {
+		return 1 // an empty grammar is contained in anything
+	}
+	return contained / total
+}
+
+// CompressionDistance is the normalized compression distance between two Compact grammars, using the Size of each grammar
+// and of the grammar of their concatenated inputs as the compressed lengths. Result: around 1 (occasionally slightly more)
+// for unrelated inputs, but well above 0 even for identical ones, as Sequitur needs several rules to encode a repeat
+// of the whole input; SimilarityBy with Compression corrects for that.
+func CompressionDistance(a, b *Compact) float64 {
+	ca, cb := a.Size(), b.Size()
+	lo, hi := ca, cb
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if hi == 0 {
+		return 0 // two empty grammars are equal
+	}
+	return float64(concatenatedSize(a, b)-lo) / float64(hi)
+}
+
+// concatenatedSize gives the Size of the grammar of the concatenated inputs of the grammars.
+func concatenatedSize(comps ...*Compact) int {
+	var input []byte
+	for _, comp := range comps {
+		if comp != nil {
+			input = append(input, comp.Bytes(comp.RootID)...)
+		}
+	}
+	return Parse(input).Compact().Size()
+}
@@ -2,6 +2,8 @@ package sequitur
 
 import (
 	"fmt"
+	"math"
+	"testing"
 )
 
 func ExampleSimilarity() {
@@ -50,3 +52,86 @@ It is useful for recognizing lexical structure in strings, and excels at very lo
 Craig Nevill-Manning, Google
 Ian Witten, University of Waikato, New Zealand
 ` // http://www.sequitur.info/
+
+func ExampleCompactIndexed_SimilarityBy() {
+
+	a := Parse([]byte(testSimilarity)).Compact().Index(nil)
+	b := Parse([]byte(testImportance)).Compact().Index(nil)
+
+	for _, m := range []SimilarityMeasure{CoverageOverlap, Jaccard, UsageCosine, Containment, Compression} {
+		fmt.Printf("%12s %7.5f %7.5f %7.5f\n", m, a.SimilarityBy(a, m), a.SimilarityBy(b, m), b.SimilarityBy(a, m))
+	}
+
+	// Output:
+	//     coverage 1.00000 0.05370 0.05370
+	//      jaccard 1.00000 0.05869 0.05869
+	//       cosine 1.00000 0.34135 0.34135
+	//  containment 1.00000 0.10140 0.00975
+	//  compression 1.00000 0.09973 0.07893
+}
+
+func TestSimilarityByEmpty(t *testing.T) {
+	empty := Parse(nil).Compact().Index(nil)
+	text := Parse([]byte(testString)).Compact().Index(nil)
+	abc, xyz := Parse([]byte("abc")).Compact().Index(nil), Parse([]byte("xyz")).Compact().Index(nil)
+	for _, m := range []SimilarityMeasure{CoverageOverlap, Jaccard, UsageCosine, Containment, Compression} {
+		if s := empty.SimilarityBy(empty, m); s != 1 {
+			t.Errorf("%v: two empty grammars have similarity %v, want 1", m, s)
+		}
+		if s := text.SimilarityBy(nil, m); s != 0 {
+			t.Errorf("%v: nil grammar has similarity %v, want 0", m, s)
+		}
+		if m != Containment {
+			if s := text.SimilarityBy(empty, m); s != 0 {
+				t.Errorf("%v: empty grammar has similarity %v, want 0", m, s)
+			}
+		}
+		if s := abc.SimilarityBy(xyz, m); s != 0 {
+			t.Errorf("%v: grammars without reused rules or shared strings have similarity %v, want 0", m, s)
+		}
+	}
+}
+
+func TestSimilarityByCompression(t *testing.T) {
+	edited := testString[:len(testString)/2] + "X" + testString[len(testString)/2:]
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"a", "a", 1, 1},
+		{"a", "b", 0, 0},
+		{"abcabc", "abcabc", 1, 1},
+		{testString, testString, 1, 1},
+		{testString, edited, 0.95, 1},
+		{edited, testString, 0.95, 1},
+	}
+	for _, tt := range tests {
+		a, b := Parse([]byte(tt.a)).Compact().Index(nil), Parse([]byte(tt.b)).Compact().Index(nil)
+		if s := a.SimilarityBy(b, Compression); s < tt.min || s > tt.max {
+			t.Errorf("SimilarityBy(%.10q, %.10q, Compression)=%v, want %v to %v", tt.a, tt.b, s, tt.min, tt.max)
+		}
+	}
+}
+
+func TestSimilarityByDuplicates(t *testing.T) {
+	// both of the rules used by the root expand to "ab", but are used different numbers of times
+	root := SymbolID(firstRuleID)
+	a, b, c := SymbolID(newRune('a')), SymbolID(newRune('b')), SymbolID(newRune('c'))
+	comp := &Compact{RootID: root, Map: map[SymbolID]CompactEntry{
+		root:     {IDs: SymbolIDslice{root + 1, c, root + 1, root + 2, c, root + 2, root + 2}},
+		root + 1: {Used: 2, IDs: SymbolIDslice{a, b}},
+		root + 2: {Used: 3, IDs: SymbolIDslice{a, b}},
+	}}
+	other := Parse([]byte("abcabxabc")).Compact().Index(nil)
+	for _, m := range []SimilarityMeasure{Jaccard, UsageCosine, Containment} {
+		var got []float64
+		for _, sid := range []SymbolID{root + 1, root + 2} {
+			ci := comp.Index(nil)
+			ci.StringToID["ab"] = sid
+			got = append(got, ci.SimilarityBy(other, m), other.SimilarityBy(ci, m))
+		}
+		if math.Abs(got[0]-got[2]) > 1e-12 || math.Abs(got[1]-got[3]) > 1e-12 {
+			t.Errorf("%v: similarity depends on which rule of ab is indexed: %v", m, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package sequitur
+
+import "errors"
+
+// Tokens gives each distinct token its own private-use rune, so that a sequence of arbitrary tokens
+// (words, lines, SAX words and so on) can be given to Parse with one terminal symbol per token.
+// The zero value is ready to use.
+type Tokens struct {
+	runes  map[string]rune
+	tokens []string
+}
+
+// ErrTooManyTokens is returned when there are no private-use runes left for a new token.
+var ErrTooManyTokens = errors.New("sequitur: too many distinct tokens")
+
+// the private-use areas of Unicode, in the order they are allocated to tokens
+var privateUse = [...]struct{ lo, hi rune }{
+	{0xe000, 0xf8ff},
+	{0xf0000, 0xffffd},
+	{0x100000, 0x10fffd},
+}
+
+func tokenRune(k int) (rune, bool) {
+	for _, pu := range privateUse {
+		if n := int(pu.hi - pu.lo + 1); k >= n {
+			k -= n
+			continue
+		}
+		return pu.lo + rune(k), true
+	}
+	return 0, false
+}
+
+func tokenIndex(r rune) (int, bool) {
+	k := 0
+	for _, pu := range privateUse {
+		if r >= pu.lo && r <= pu.hi {
+			return k + int(r-pu.lo), true
+		}
+		k += int(pu.hi - pu.lo + 1)
+	}
+	return 0, false
+}
+
+// Rune gives the rune representing tok, allocating a new one if tok has not been seen before.
+func (t *Tokens) Rune(tok string) (rune, error) {
+	if r, ok := t.runes[tok]; ok {
+		return r, nil
+	}
+	r, ok := tokenRune(len(t.tokens))
+	if !ok {
+		return 0, ErrTooManyTokens
+	}
+	if t.runes == nil {
+		t.runes = make(map[string]rune)
+	}
+	t.runes[tok] = r
+	t.tokens = append(t.tokens, tok)
+	return r, nil
+}
+
+// Append appends the UTF-8 encoding of the rune representing tok to b.
+func (t *Tokens) Append(b []byte, tok string) ([]byte, error) {
+	r, err := t.Rune(tok)
+	if err != nil {
+		return b, err
+	}
+	return append(b, string(r)...), nil
+}
+
+// Token gives the token represented by a terminal SymbolID, if there is one.
+func (t *Tokens) Token(sid SymbolID) (string, bool) {
+	if sid == EmptySymbolID || sid.IsRule() {
+		return "", false
+	}
+	k, ok := tokenIndex(runeOrByte(sid).rune())
+	if !ok || k >= len(t.tokens) {
+		return "", false
+	}
+	return t.tokens[k], true
+}
+
+// Len gives the number of distinct tokens seen.
+func (t *Tokens) Len() int {
+	return len(t.tokens)
+}
@@ -0,0 +1,63 @@
+package sequitur
+
+import (
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	var toks Tokens
+	words := []string{"the", "cat", "sat", "on", "the", "mat", "the", "cat", "sat"}
+	var input []byte
+	for _, w := range words {
+		var err error
+		if input, err = toks.Append(input, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if toks.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", toks.Len())
+	}
+	comp := Parse(input).Compact()
+	var got []string
+	var expand func(SymbolID)
+	expand = func(id SymbolID) {
+		if id.IsRule() {
+			for _, sid := range comp.Map[id].IDs {
+				expand(sid)
+			}
+			return
+		}
+		tok, ok := toks.Token(id)
+		if !ok {
+			t.Fatalf("no token for %v", id)
+		}
+		got = append(got, tok)
+	}
+	expand(comp.RootID)
+	if len(got) != len(words) {
+		t.Fatalf("got %d tokens, want %d", len(got), len(words))
+	}
+	for i := range got {
+		if got[i] != words[i] {
+			t.Errorf("token %d = %q, want %q", i, got[i], words[i])
+		}
+	}
+	if _, ok := toks.Token(comp.RootID); ok {
+		t.Error("rule has a token")
+	}
+}
+
+func TestTokenRunes(t *testing.T) {
+	for _, k := range []int{0, 6399, 6400, 6400 + 65533, 6400 + 65534, 6400 + 2*65534 - 1} {
+		r, ok := tokenRune(k)
+		if !ok {
+			t.Fatalf("no rune for token %d", k)
+		}
+		if got, ok := tokenIndex(r); !ok || got != k {
+			t.Errorf("token %d has rune %U which maps back to %d", k, r, got)
+		}
+	}
+	if _, ok := tokenRune(6400 + 2*65534); ok {
+		t.Error("too many tokens allowed")
+	}
+}
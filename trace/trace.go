@@ -0,0 +1,340 @@
+// Package trace records how a sequitur grammar is built, one input symbol at a time,
+// and renders the record as a self-contained HTML page animating how rules form and dissolve.
+// Every step holds a copy of the whole grammar, so it is meant for small inputs.
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	sequitur "github.com/dgryski/go-sequitur"
+)
+
+// Kind of an Event.
+type Kind int
+
+const (
+	RuleCreated    Kind = iota // a digram seen twice was replaced by a new rule
+	DigramReplaced             // a digram was replaced by the existing rule consisting of it
+	RuleInlined                // a rule used only once was replaced by its contents
+)
+
+// String for Kind.
+func (k Kind) String() string {
+	switch k {
+	case RuleCreated:
+		return "created"
+	case DigramReplaced:
+		return "replaced"
+	case RuleInlined:
+		return "inlined"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Event is one change made to the grammar by the algorithm.
+type Event struct {
+	Kind   Kind
+	Rule   sequitur.SymbolID
+	Digram [2]sequitur.SymbolID // the digram the rule was created for, for RuleCreated only
+}
+
+// Step is the grammar after appending one symbol of the input.
+type Step struct {
+	Symbol  sequitur.SymbolID
+	Offset  int     // the byte offset of Symbol in the input
+	Events  []Event // the changes made while appending Symbol, in order
+	Grammar *sequitur.Compact
+}
+
+// Trace of building the grammar of an input.
+type Trace struct {
+	Input []byte
+	Steps []Step
+	names map[sequitur.SymbolID]string
+}
+
+// Record the grammar of input after each of its symbols.
+func Record(input []byte) *Trace {
+	t := &Trace{
+		Input: input,
+		names: make(map[sequitur.SymbolID]string),
+	}
+	var events []Event
+	offset := 0
+	hooks := &sequitur.Hooks{
+		RuleCreated: func(g *sequitur.Grammar, rule sequitur.SymbolID, digram [2]sequitur.SymbolID) {
+			events = append(events, Event{Kind: RuleCreated, Rule: rule, Digram: digram})
+		},
+		DigramReplaced: func(g *sequitur.Grammar, rule sequitur.SymbolID) {
+			events = append(events, Event{Kind: DigramReplaced, Rule: rule})
+		},
+		RuleInlined: func(g *sequitur.Grammar, rule sequitur.SymbolID) {
+			events = append(events, Event{Kind: RuleInlined, Rule: rule})
+		},
+		SymbolAppended: func(g *sequitur.Grammar, sym sequitur.SymbolID) {
+			comp := g.Compact()
+			if len(t.Steps) == 0 {
+				t.names[comp.RootID] = "S"
+			}
+			for _, e := range events {
+				if e.Kind == RuleCreated {
+					t.names[e.Rule] = fmt.Sprint("R", len(t.names))
+				}
+			}
+			t.Steps = append(t.Steps, Step{
+				Symbol:  sym,
+				Offset:  offset,
+				Events:  events,
+				Grammar: comp,
+			})
+			offset += len(sym.Bytes(comp))
+			events = nil
+		},
+	}
+	// reading from a byte slice without a deadline cannot fail
+	_, _ = sequitur.ParseContext(context.Background(), bytes.NewReader(input), sequitur.ParseOptions{Hooks: hooks})
+	return t
+}
+
+// Name of a symbol in the trace: S for the top-level rule, R1, R2 and so on for the other rules
+// in the order they were created, and the escaped text of terminals.
+func (t *Trace) Name(sid sequitur.SymbolID) string {
+	if name, ok := t.names[sid]; ok {
+		return name
+	}
+	return sid.String()
+}
+
+// String of an Event, using the names of the trace.
+func (t *Trace) eventString(e Event) string {
+	if e.Kind == RuleCreated {
+		return fmt.Sprintf("%s %s -> %s %s", e.Kind, t.Name(e.Rule), t.Name(e.Digram[0]), t.Name(e.Digram[1]))
+	}
+	return fmt.Sprintf("%s %s", e.Kind, t.Name(e.Rule))
+}
+
+// rules of the grammar of a step, the top-level rule first and then in the order they were created.
+func (t *Trace) rules(s Step) sequitur.SymbolIDslice {
+	ids := make(sequitur.SymbolIDslice, 0, len(s.Grammar.Map))
+	for id := range s.Grammar.Map {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (t *Trace) rhs(s Step, id sequitur.SymbolID) string {
+	names := make([]string, len(s.Grammar.Map[id].IDs))
+	for i, sid := range s.Grammar.Map[id].IDs {
+		names[i] = t.Name(sid)
+	}
+	return strings.Join(names, " ")
+}
+
+// WriteText writes each step: the symbol appended, the changes it caused and the resulting rules.
+func (t *Trace) WriteText(w io.Writer) error {
+	for _, s := range t.Steps {
+		if _, err := fmt.Fprintf(w, "%d %s\n", s.Offset, s.Symbol); err != nil {
+			return err
+		}
+		for _, e := range s.Events {
+			if _, err := fmt.Fprintln(w, "  !", t.eventString(e)); err != nil {
+				return err
+			}
+		}
+		for _, id := range t.rules(s) {
+			if _, err := fmt.Fprintln(w, "  ", t.Name(id), "->", t.rhs(s, id)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// the steps as given to the script of the HTML page
+type jsonStep struct {
+	Offset int        `json:"offset"`
+	Length int        `json:"length"`
+	Events []string   `json:"events"`
+	Rules  []jsonRule `json:"rules"`
+}
+
+type jsonRule struct {
+	Name      string       `json:"name"`
+	Symbols   []jsonSymbol `json:"symbols"`
+	Expansion string       `json:"expansion"`
+	Used      int          `json:"used"`
+}
+
+type jsonSymbol struct {
+	Text string `json:"text"`
+	Rule bool   `json:"rule,omitempty"`
+}
+
+// WriteHTML writes a page with no external dependencies which steps through the trace,
+// highlighting the rules created, changed and removed at each step.
+func (t *Trace) WriteHTML(w io.Writer, title string) error {
+	steps := make([]jsonStep, len(t.Steps))
+	for i, s := range t.Steps {
+		js := jsonStep{
+			Offset: s.Offset,
+			Length: len(s.Symbol.Bytes(s.Grammar)),
+			Events: []string{},
+		}
+		for _, e := range s.Events {
+			js.Events = append(js.Events, t.eventString(e))
+		}
+		for _, id := range t.rules(s) {
+			r := jsonRule{
+				Name:      t.Name(id),
+				Expansion: string(s.Grammar.Bytes(id)),
+				Used:      s.Grammar.Map[id].Used,
+			}
+			for _, sid := range s.Grammar.Map[id].IDs {
+				r.Symbols = append(r.Symbols, jsonSymbol{Text: t.Name(sid), Rule: sid.IsRule()})
+			}
+			js.Rules = append(js.Rules, r)
+		}
+		steps[i] = js
+	}
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return err
+	}
+	return page.Execute(w, struct {
+		Title string
+		Input string
+		Steps template.JS
+	}{title, string(t.Input), template.JS(data)})
+}
+
+var page = template.Must(template.New("trace").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+#input { font-family: monospace; font-size: 150%; white-space: pre-wrap; }
+#input .done { background: #def; }
+#input .current { background: #fd8; }
+table { border-collapse: collapse; margin: 1em 0; font-family: monospace; font-size: 120%; }
+td { padding: 0.2em 0.6em; }
+tr.new { background: #cfc; }
+tr.changed { background: #ffc; }
+tr.removed { background: #fcc; text-decoration: line-through; }
+.rule { color: #06c; font-weight: bold; }
+.expansion { color: #888; }
+#events { color: #a40; font-family: monospace; min-height: 3em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div id="input">{{.Input}}</div>
+<p>
+<button id="first">&#x23EE;</button>
+<button id="prev">&#x23F4;</button>
+<button id="play">&#x23EF;</button>
+<button id="next">&#x23F5;</button>
+<button id="last">&#x23ED;</button>
+<input id="slider" type="range" min="0" value="0">
+<span id="counter"></span>
+</p>
+<div id="events"></div>
+<table><tbody id="rules"></tbody></table>
+<script>
+var input = document.getElementById("input").textContent;
+var steps = {{.Steps}};
+var current = 0, timer = null;
+
+function text(tag, content, cls) {
+	var e = document.createElement(tag);
+	e.textContent = content;
+	if (cls) e.className = cls;
+	return e;
+}
+
+function rhs(rule) {
+	return rule.symbols.map(function(s) { return s.text; }).join(" ");
+}
+
+function row(rule, cls) {
+	var tr = document.createElement("tr");
+	tr.className = cls;
+	tr.appendChild(text("td", rule.name, "rule"));
+	tr.appendChild(text("td", "→"));
+	var td = document.createElement("td");
+	rule.symbols.forEach(function(s) {
+		td.appendChild(text("span", s.text, s.rule ? "rule" : ""));
+		td.appendChild(document.createTextNode(" "));
+	});
+	tr.appendChild(td);
+	tr.appendChild(text("td", "used " + rule.used));
+	tr.appendChild(text("td", JSON.stringify(rule.expansion), "expansion"));
+	return tr;
+}
+
+function show(i) {
+	current = Math.max(0, Math.min(i, steps.length - 1));
+	var step = steps[current];
+	var before = {};
+	if (current > 0) steps[current - 1].rules.forEach(function(r) { before[r.name] = r; });
+
+	var div = document.getElementById("input");
+	div.textContent = "";
+	var enc = new TextEncoder(), dec = new TextDecoder();
+	var bytes = enc.encode(input);
+	div.appendChild(text("span", dec.decode(bytes.slice(0, step.offset)), "done"));
+	div.appendChild(text("span", dec.decode(bytes.slice(step.offset, step.offset + step.length)), "current"));
+	div.appendChild(text("span", dec.decode(bytes.slice(step.offset + step.length))));
+
+	var events = document.getElementById("events");
+	events.textContent = "";
+	step.events.forEach(function(e) { events.appendChild(text("div", e)); });
+
+	var tbody = document.getElementById("rules");
+	tbody.textContent = "";
+	var now = {};
+	step.rules.forEach(function(r) {
+		now[r.name] = true;
+		var cls = "";
+		if (current > 0 && !before[r.name]) cls = "new";
+		else if (before[r.name] && rhs(before[r.name]) !== rhs(r)) cls = "changed";
+		tbody.appendChild(row(r, cls));
+	});
+	Object.keys(before).forEach(function(name) {
+		if (!now[name]) tbody.appendChild(row(before[name], "removed"));
+	});
+
+	document.getElementById("slider").value = current;
+	document.getElementById("counter").textContent = "step " + (current + 1) + " of " + steps.length;
+}
+
+function play() {
+	if (timer) { clearInterval(timer); timer = null; return; }
+	if (current == steps.length - 1) show(0);
+	timer = setInterval(function() {
+		if (current >= steps.length - 1) { clearInterval(timer); timer = null; return; }
+		show(current + 1);
+	}, 1000);
+}
+
+document.getElementById("slider").max = Math.max(0, steps.length - 1);
+document.getElementById("slider").oninput = function() { show(+this.value); };
+document.getElementById("first").onclick = function() { show(0); };
+document.getElementById("prev").onclick = function() { show(current - 1); };
+document.getElementById("next").onclick = function() { show(current + 1); };
+document.getElementById("last").onclick = function() { show(steps.length - 1); };
+document.getElementById("play").onclick = play;
+if (steps.length > 0) show(0);
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,66 @@
+package trace
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleTrace_WriteText() {
+	// one of the examples from https://en.wikipedia.org/wiki/Sequitur_algorithm
+	t := Record([]byte("abaaba"))
+	if err := t.WriteText(os.Stdout); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// 0 a
+	//    S -> a
+	// 1 b
+	//    S -> a b
+	// 2 a
+	//    S -> a b a
+	// 3 a
+	//    S -> a b a a
+	// 4 b
+	//   ! created R1 -> a b
+	//    S -> R1 a R1
+	//    R1 -> a b
+	// 5 a
+	//   ! created R2 -> R1 a
+	//   ! inlined R1
+	//    S -> R2 R2
+	//    R2 -> a b a
+}
+
+func TestWriteHTML(t *testing.T) {
+	tr := Record([]byte("abcab</script>"))
+	if len(tr.Steps) != len("abcab</script>") {
+		t.Fatalf("got %d steps, want %d", len(tr.Steps), len("abcab</script>"))
+	}
+	var b bytes.Buffer
+	if err := tr.WriteHTML(&b, "a <trace>"); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	if strings.Count(page, "</script>") != 1 {
+		t.Error("input not escaped within the script")
+	}
+	for _, want := range []string{"<title>a &lt;trace&gt;</title>", `"created R1 -\u003e a b"`, `"name":"R1"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %s", want)
+		}
+	}
+}
+
+func TestRecordEmpty(t *testing.T) {
+	tr := Record(nil)
+	if len(tr.Steps) != 0 {
+		t.Errorf("got %d steps for empty input", len(tr.Steps))
+	}
+	var b bytes.Buffer
+	if err := tr.WriteHTML(&b, "empty"); err != nil {
+		t.Fatal(err)
+	}
+}